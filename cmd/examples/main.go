@@ -15,7 +15,7 @@ func main() {
 	}
 
 	type User struct {
-		ID    int    `durazzo:"primary_key""`
+		ID    int    `durazzo:"primary_key"`
 		Name  string `durazzo:"size:100"`
 		Email string `durazzo:"unique"`
 	}
@@ -25,7 +25,10 @@ func main() {
 		Price int
 	}
 
-	newDurazzo := durazzo.NewDurazzo(config)
+	newDurazzo, err := durazzo.NewDurazzo(config)
+	if err != nil {
+		log.Fatalf("Error connecting to the database: %v", err)
+	}
 	defer func(newDurazzo *durazzo.Durazzo) {
 		err := newDurazzo.Delete("user").Where("id", "1").Run()
 		err = newDurazzo.Close()
@@ -33,7 +36,7 @@ func main() {
 			log.Fatalf("Error closing Durazzo:%v", err)
 		}
 	}(newDurazzo)
-	err := newDurazzo.AutoMigrate(&User{}, &Product{})
+	err = newDurazzo.AutoMigrate(&User{}, &Product{})
 	if err != nil {
 		log.Fatalf("Error creating table: %v", err)
 	}
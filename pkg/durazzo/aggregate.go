@@ -0,0 +1,90 @@
+package durazzo
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// GroupBy groups the rows by the given columns, without Columns they are selected next to the aggregates
+func (st *SelectType) GroupBy(columns ...string) *SelectType {
+	st.groupBy = append(st.groupBy, columns...)
+	return st
+}
+
+// Having filters the groups, the conditions are joined by AND and reference aggregate
+// expressions rather than their aliases, e.g. Having(Gt("SUM(amount)", 100)). The column of
+// COUNT, SUM, AVG, MIN and MAX is quoted like any other column.
+func (st *SelectType) Having(conditions ...Condition) *SelectType {
+	st.having = append(st.having, conditions...)
+	return st
+}
+
+// Sum selects the sum of column aliased as sum_<column>
+func (st *SelectType) Sum(column string) *SelectType {
+	return st.aggregate("SUM", column)
+}
+
+// Avg selects the average of column aliased as avg_<column>
+func (st *SelectType) Avg(column string) *SelectType {
+	return st.aggregate("AVG", column)
+}
+
+// Min selects the smallest value of column aliased as min_<column>
+func (st *SelectType) Min(column string) *SelectType {
+	return st.aggregate("MIN", column)
+}
+
+// Max selects the largest value of column aliased as max_<column>
+func (st *SelectType) Max(column string) *SelectType {
+	return st.aggregate("MAX", column)
+}
+
+// aggregate adds function of column to the selected values, qualified columns are aliased by their column name.
+// The column is quoted with the other selected columns, expressions are rejected since they make no alias.
+func (st *SelectType) aggregate(function, column string) *SelectType {
+	if !simpleIdentifierRegex.MatchString(column) {
+		st.err = fmt.Errorf("%s expects a column, got %q", function, column)
+		return st
+	}
+	alias := strings.ToLower(function) + "_" + column[strings.LastIndex(column, ".")+1:]
+	st.aggregates = append(st.aggregates, fmt.Sprintf("%s(%s) AS %s", function, column, alias))
+	return st
+}
+
+// Count returns the number of rows matching the query, or the number of groups when it is grouped.
+// Orders, limits and offsets are ignored.
+func (st *SelectType) Count(ctx context.Context) (int64, error) {
+	if st.err != nil {
+		return 0, st.err
+	}
+	startTime := time.Now()
+
+	// the count needs none of the selected columns
+	query, args, err := st.filteredQuery()
+	if err != nil {
+		return 0, err
+	}
+	query.Orders, query.Limit, query.Offset = nil, 0, 0
+	query.Columns = []string{"COUNT(*)"}
+	if len(query.GroupBy) > 0 {
+		query.Columns = []string{"1"}
+	}
+
+	rendered, err := st.queryBuilder.BuildSelectQuery(query)
+	if err != nil {
+		return 0, err
+	}
+	if len(query.GroupBy) > 0 {
+		rendered = fmt.Sprintf("SELECT COUNT(*) FROM (%s) %s", rendered, st.dialect.Quote("grouped"))
+	}
+
+	var count int64
+	if err := st.conn.QueryRowContext(ctx, rendered, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+	log.Printf("Query : %s took %v to run\n\n", rendered, time.Since(startTime))
+	return count, nil
+}
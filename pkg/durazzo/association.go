@@ -0,0 +1,269 @@
+package durazzo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/util"
+	"reflect"
+	"strings"
+)
+
+// AssociationType manages the join table rows linking a model to the models of a many2many association.
+// Every change runs in a transaction and is mirrored in the association field of the model.
+type AssociationType struct {
+	*Durazzo
+	owner    reflect.Value
+	relation *util.Relation
+	err      error
+}
+
+// Association initializes the management of the many2many association name of model, a pointer to a struct
+func (d *Durazzo) Association(model interface{}, name string) *AssociationType {
+	at := &AssociationType{Durazzo: d}
+	owner := reflect.ValueOf(model)
+	if owner.Kind() != reflect.Ptr || owner.IsNil() || owner.Elem().Kind() != reflect.Struct {
+		at.err = fmt.Errorf("failed to initialize Association: model must be a pointer to a struct, got %T", model)
+		return at
+	}
+	at.owner = owner.Elem()
+
+	relations, err := util.ParseRelations(at.owner.Type(), d.naming)
+	if err != nil {
+		at.err = fmt.Errorf("failed to initialize Association: %w", err)
+		return at
+	}
+	for _, relation := range relations {
+		if relation.Name == name {
+			at.relation = relation
+		}
+	}
+	switch {
+	case at.relation == nil:
+		at.err = fmt.Errorf("failed to initialize Association: %s has no association %s", at.owner.Type().Name(), name)
+	case at.relation.Kind != util.ManyToMany:
+		at.err = fmt.Errorf("failed to initialize Association: %s.%s is not a many2many association", at.owner.Type().Name(), name)
+	}
+	return at
+}
+
+// Append links models to the owner, models without a key are inserted first.
+// Links that already exist are kept and the models already in the association field are not added twice.
+func (at *AssociationType) Append(ctx context.Context, models ...interface{}) error {
+	values, err := at.associated(models)
+	if err != nil {
+		return err
+	}
+	err = at.Transaction(ctx, func(tx *Durazzo) error {
+		return at.link(ctx, tx, values)
+	})
+	if err != nil {
+		return err
+	}
+	field := at.owner.FieldByIndex(at.relation.Index)
+	current := structValues(field.Addr())
+	present := map[string]bool{}
+	for _, value := range current {
+		if key, ok := preloadKey(value.FieldByIndex(at.relation.ForeignKey.Index)); ok {
+			present[key] = true
+		}
+	}
+	for _, value := range values {
+		key, _ := preloadKey(value.FieldByIndex(at.relation.ForeignKey.Index))
+		if !present[key] {
+			present[key] = true
+			current = append(current, value)
+		}
+	}
+	setAssociation(field, current)
+	return nil
+}
+
+// Replace links the owner to exactly models, the links to every other model are removed
+func (at *AssociationType) Replace(ctx context.Context, models ...interface{}) error {
+	values, err := at.associated(models)
+	if err != nil {
+		return err
+	}
+	err = at.Transaction(ctx, func(tx *Durazzo) error {
+		if err := at.unlink(ctx, tx, nil); err != nil {
+			return err
+		}
+		return at.link(ctx, tx, values)
+	})
+	if err != nil {
+		return err
+	}
+	setAssociation(at.owner.FieldByIndex(at.relation.Index), values)
+	return nil
+}
+
+// Remove unlinks models from the owner, the models themselves are kept
+func (at *AssociationType) Remove(ctx context.Context, models ...interface{}) error {
+	values, err := at.associated(models)
+	if err != nil {
+		return err
+	}
+	var keys []interface{}
+	removed := map[string]bool{}
+	for _, value := range values {
+		key := value.FieldByIndex(at.relation.ForeignKey.Index)
+		if normalized, ok := preloadKey(key); ok {
+			keys = append(keys, key.Interface())
+			removed[normalized] = true
+		}
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	err = at.Transaction(ctx, func(tx *Durazzo) error {
+		return at.unlink(ctx, tx, keys)
+	})
+	if err != nil {
+		return err
+	}
+
+	field := at.owner.FieldByIndex(at.relation.Index)
+	var kept []reflect.Value
+	for _, value := range structValues(field.Addr()) {
+		if key, _ := preloadKey(value.FieldByIndex(at.relation.ForeignKey.Index)); !removed[key] {
+			kept = append(kept, value)
+		}
+	}
+	setAssociation(field, kept)
+	return nil
+}
+
+// Clear unlinks every model from the owner
+func (at *AssociationType) Clear(ctx context.Context) error {
+	if at.err != nil {
+		return at.err
+	}
+	err := at.Transaction(ctx, func(tx *Durazzo) error {
+		return at.unlink(ctx, tx, nil)
+	})
+	if err != nil {
+		return err
+	}
+	setAssociation(at.owner.FieldByIndex(at.relation.Index), nil)
+	return nil
+}
+
+// Count returns the number of models linked to the owner
+func (at *AssociationType) Count(ctx context.Context) (int64, error) {
+	ownerKey, err := at.ownerKey()
+	if err != nil {
+		return 0, err
+	}
+	var args []interface{}
+	where, err := buildConditions(at.dialect, []Condition{Eq(at.relation.JoinForeignKey, ownerKey)}, &args)
+	if err != nil {
+		return 0, err
+	}
+	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", quoteTable(at.dialect, at.relation.JoinTable), where)
+
+	var count int64
+	if err := at.conn.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count association %s: %w", at.relation.Name, err)
+	}
+	return count, nil
+}
+
+// ownerKey returns the key of the owner stored in the join table, the owner must have been inserted
+func (at *AssociationType) ownerKey() (interface{}, error) {
+	if at.err != nil {
+		return nil, at.err
+	}
+	key := at.owner.FieldByIndex(at.relation.References.Index)
+	if _, ok := preloadKey(key); !ok {
+		return nil, errors.New("the model has no key, insert it before managing its associations")
+	}
+	return key.Interface(), nil
+}
+
+// associated returns the addressable structs held by models, structs or pointers to structs and slices of them
+func (at *AssociationType) associated(models []interface{}) ([]reflect.Value, error) {
+	if at.err != nil {
+		return nil, at.err
+	}
+	var values []reflect.Value
+	for _, model := range models {
+		value := reflect.ValueOf(model)
+		if value.Kind() == reflect.Struct {
+			// a struct passed by value cannot receive its generated key, it is linked from a copy
+			copied := reflect.New(value.Type())
+			copied.Elem().Set(value)
+			value = copied
+		}
+		for _, structValue := range structValues(value) {
+			if structValue.Type() != at.relation.Type {
+				return nil, fmt.Errorf("association %s holds %s, got %s", at.relation.Name, at.relation.Type, structValue.Type())
+			}
+			values = append(values, structValue)
+		}
+	}
+	return values, nil
+}
+
+// link inserts the models without a key and the join table rows pointing to them
+func (at *AssociationType) link(ctx context.Context, tx *Durazzo, values []reflect.Value) error {
+	ownerKey, err := at.ownerKey()
+	if err != nil {
+		return err
+	}
+
+	var keys []interface{}
+	for _, value := range values {
+		key := value.FieldByIndex(at.relation.ForeignKey.Index)
+		if _, ok := preloadKey(key); !ok {
+			if err := tx.Insert(value.Addr().Interface()).RunContext(ctx); err != nil {
+				return err
+			}
+		}
+		keys = append(keys, key.Interface())
+	}
+
+	columns := []string{at.dialect.Quote(at.relation.JoinForeignKey), at.dialect.Quote(at.relation.JoinReferences)}
+	batchSize := at.dialect.MaxParameters() / len(columns)
+	for start := 0; start < len(keys); start += batchSize {
+		chunk := keys[start:min(start+batchSize, len(keys))]
+		var args []interface{}
+		rows := make([]string, 0, len(chunk))
+		for _, key := range chunk {
+			rows = append(rows, fmt.Sprintf("(%s, %s)", bind(at.dialect, &args, ownerKey), bind(at.dialect, &args, key)))
+		}
+		// links that already exist are skipped
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES %s %s",
+			quoteTable(at.dialect, at.relation.JoinTable), strings.Join(columns, ", "), strings.Join(rows, ", "),
+			at.dialect.OnConflict(columns, nil, columns))
+		if _, err := tx.conn.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("failed to link association %s: %w", at.relation.Name, err)
+		}
+	}
+	return nil
+}
+
+// unlink deletes the join table rows of the owner pointing to keys, or all of them when keys is nil
+func (at *AssociationType) unlink(ctx context.Context, tx *Durazzo, keys []interface{}) error {
+	ownerKey, err := at.ownerKey()
+	if err != nil {
+		return err
+	}
+	owned := Eq(at.relation.JoinForeignKey, ownerKey)
+	if keys == nil {
+		if err := tx.Delete(at.relation.JoinTable).Filter(owned).RunContext(ctx); err != nil {
+			return fmt.Errorf("failed to unlink association %s: %w", at.relation.Name, err)
+		}
+		return nil
+	}
+
+	// one parameter is taken by the owner key
+	batchSize := at.dialect.MaxParameters() - 1
+	for start := 0; start < len(keys); start += batchSize {
+		chunk := keys[start:min(start+batchSize, len(keys))]
+		if err := tx.Delete(at.relation.JoinTable).Filter(owned, In(at.relation.JoinReferences, chunk...)).RunContext(ctx); err != nil {
+			return fmt.Errorf("failed to unlink association %s: %w", at.relation.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,276 @@
+package durazzo
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Condition is a predicate of a WHERE clause, conditions are rendered when the query runs
+// so that placeholders are numbered across every clause of the statement
+type Condition interface {
+	build(dialect Dialect, args *[]interface{}) (string, error)
+}
+
+// Where creates a condition from a column, an operator and a value.
+// Supported operators are =, <>, !=, <, <=, >, >=, LIKE, ILIKE, IN, NOT IN, BETWEEN, IS NULL and IS NOT NULL
+func Where(column, operator string, value interface{}) Condition {
+	switch strings.ToUpper(strings.TrimSpace(operator)) {
+	case "=", "<>", "!=", "<", "<=", ">", ">=", "LIKE":
+		return &comparison{column: column, operator: strings.ToUpper(strings.TrimSpace(operator)), value: value}
+	case "ILIKE":
+		return ILike(column, value)
+	case "IN":
+		return In(column, value)
+	case "NOT IN":
+		return NotIn(column, value)
+	case "BETWEEN":
+		values := expandValues([]interface{}{value})
+		if len(values) != 2 {
+			return &invalidCondition{err: fmt.Errorf("BETWEEN on %s expects two values, got %d", column, len(values))}
+		}
+		return Between(column, values[0], values[1])
+	case "IS NULL":
+		return IsNull(column)
+	case "IS NOT NULL":
+		return IsNotNull(column)
+	default:
+		return &invalidCondition{err: fmt.Errorf("unsupported operator %q on %s", operator, column)}
+	}
+}
+
+// Eq matches rows where column = value
+func Eq(column string, value interface{}) Condition {
+	return &comparison{column: column, operator: "=", value: value}
+}
+
+// Neq matches rows where column <> value
+func Neq(column string, value interface{}) Condition {
+	return &comparison{column: column, operator: "<>", value: value}
+}
+
+// Lt matches rows where column < value
+func Lt(column string, value interface{}) Condition {
+	return &comparison{column: column, operator: "<", value: value}
+}
+
+// Lte matches rows where column <= value
+func Lte(column string, value interface{}) Condition {
+	return &comparison{column: column, operator: "<=", value: value}
+}
+
+// Gt matches rows where column > value
+func Gt(column string, value interface{}) Condition {
+	return &comparison{column: column, operator: ">", value: value}
+}
+
+// Gte matches rows where column >= value
+func Gte(column string, value interface{}) Condition {
+	return &comparison{column: column, operator: ">=", value: value}
+}
+
+// Like matches rows where column LIKE pattern
+func Like(column string, pattern interface{}) Condition {
+	return &comparison{column: column, operator: "LIKE", value: pattern}
+}
+
+// ILike matches rows where column is LIKE pattern ignoring case
+func ILike(column string, pattern interface{}) Condition {
+	return &iLikeCondition{column: column, pattern: pattern}
+}
+
+// In matches rows where column is one of values, a single slice argument is expanded
+func In(column string, values ...interface{}) Condition {
+	return &inCondition{column: column, values: expandValues(values)}
+}
+
+// NotIn matches rows where column is none of values, a single slice argument is expanded
+func NotIn(column string, values ...interface{}) Condition {
+	return &inCondition{column: column, values: expandValues(values), negate: true}
+}
+
+// Between matches rows where column is within from and to, both inclusive
+func Between(column string, from, to interface{}) Condition {
+	return &betweenCondition{column: column, from: from, to: to}
+}
+
+// IsNull matches rows where column IS NULL
+func IsNull(column string) Condition {
+	return &nullCondition{column: column}
+}
+
+// IsNotNull matches rows where column IS NOT NULL
+func IsNotNull(column string) Condition {
+	return &nullCondition{column: column, negate: true}
+}
+
+// And matches rows satisfying every condition
+func And(conditions ...Condition) Condition {
+	return &groupCondition{operator: "AND", conditions: conditions}
+}
+
+// Or matches rows satisfying at least one condition
+func Or(conditions ...Condition) Condition {
+	return &groupCondition{operator: "OR", conditions: conditions}
+}
+
+// Not negates a condition
+func Not(condition Condition) Condition {
+	return &notCondition{condition: condition}
+}
+
+type comparison struct {
+	column   string
+	operator string
+	value    interface{}
+}
+
+func (c *comparison) build(dialect Dialect, args *[]interface{}) (string, error) {
+	if c.value == nil {
+		return "", fmt.Errorf("nil value compared with %s on %s, use IsNull or IsNotNull instead", c.operator, c.column)
+	}
+	return fmt.Sprintf("%s %s %s", quoteColumn(dialect, c.column), c.operator, bind(dialect, args, c.value)), nil
+}
+
+type iLikeCondition struct {
+	column  string
+	pattern interface{}
+}
+
+func (c *iLikeCondition) build(dialect Dialect, args *[]interface{}) (string, error) {
+	return dialect.CaseInsensitiveLike(quoteColumn(dialect, c.column), bind(dialect, args, c.pattern)), nil
+}
+
+type inCondition struct {
+	column string
+	values []interface{}
+	negate bool
+}
+
+func (c *inCondition) build(dialect Dialect, args *[]interface{}) (string, error) {
+	if len(c.values) == 0 {
+		// an empty list never matches, so NOT IN of it always does
+		if c.negate {
+			return "1 = 1", nil
+		}
+		return "1 = 0", nil
+	}
+
+	placeholders := make([]string, len(c.values))
+	for i, value := range c.values {
+		placeholders[i] = bind(dialect, args, value)
+	}
+
+	operator := "IN"
+	if c.negate {
+		operator = "NOT IN"
+	}
+	return fmt.Sprintf("%s %s (%s)", quoteColumn(dialect, c.column), operator, strings.Join(placeholders, ", ")), nil
+}
+
+type betweenCondition struct {
+	column   string
+	from, to interface{}
+}
+
+func (c *betweenCondition) build(dialect Dialect, args *[]interface{}) (string, error) {
+	from := bind(dialect, args, c.from)
+	to := bind(dialect, args, c.to)
+	return fmt.Sprintf("%s BETWEEN %s AND %s", quoteColumn(dialect, c.column), from, to), nil
+}
+
+type nullCondition struct {
+	column string
+	negate bool
+}
+
+func (c *nullCondition) build(dialect Dialect, _ *[]interface{}) (string, error) {
+	if c.negate {
+		return quoteColumn(dialect, c.column) + " IS NOT NULL", nil
+	}
+	return quoteColumn(dialect, c.column) + " IS NULL", nil
+}
+
+type groupCondition struct {
+	operator   string
+	conditions []Condition
+}
+
+func (c *groupCondition) build(dialect Dialect, args *[]interface{}) (string, error) {
+	if len(c.conditions) == 0 {
+		// mirror the identities of the operators: an empty AND is true and an empty OR is false
+		if c.operator == "AND" {
+			return "1 = 1", nil
+		}
+		return "1 = 0", nil
+	}
+
+	parts := make([]string, 0, len(c.conditions))
+	for _, condition := range c.conditions {
+		part, err := condition.build(dialect, args)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return "(" + strings.Join(parts, " "+c.operator+" ") + ")", nil
+}
+
+type notCondition struct {
+	condition Condition
+}
+
+func (c *notCondition) build(dialect Dialect, args *[]interface{}) (string, error) {
+	part, err := c.condition.build(dialect, args)
+	if err != nil {
+		return "", err
+	}
+	return "NOT (" + part + ")", nil
+}
+
+type invalidCondition struct {
+	err error
+}
+
+func (c *invalidCondition) build(Dialect, *[]interface{}) (string, error) {
+	return "", c.err
+}
+
+// buildConditions renders conditions joined by AND, it returns an empty string when there are none
+func buildConditions(dialect Dialect, conditions []Condition, args *[]interface{}) (string, error) {
+	parts := make([]string, 0, len(conditions))
+	for _, condition := range conditions {
+		part, err := condition.build(dialect, args)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, part)
+	}
+	return strings.Join(parts, " AND "), nil
+}
+
+// bind appends value to args and returns the placeholder referencing it
+func bind(dialect Dialect, args *[]interface{}, value interface{}) string {
+	*args = append(*args, value)
+	return dialect.Placeholder(len(*args))
+}
+
+// expandValues flattens a single slice argument into its elements, byte slices are kept as values
+func expandValues(values []interface{}) []interface{} {
+	if len(values) != 1 || values[0] == nil {
+		return values
+	}
+	value := reflect.ValueOf(values[0])
+	if (value.Kind() != reflect.Slice && value.Kind() != reflect.Array) || value.Type().Elem().Kind() == reflect.Uint8 {
+		return values
+	}
+
+	expanded := make([]interface{}, value.Len())
+	for i := range expanded {
+		expanded[i] = value.Index(i).Interface()
+	}
+	return expanded
+}
@@ -0,0 +1,99 @@
+package durazzo_test
+
+import (
+	"github.com/EraldCaka/durazzo/pkg/durazzo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func insertConditionUsers(t *testing.T, d *durazzo.Durazzo) {
+	users := []User{
+		{ID: 1, Name: "kris", Email: "kris@yahoo.com"},
+		{ID: 2, Name: "erald", Email: "erald@yahoo.com"},
+		{ID: 3, Name: "jessie", Email: "jessie@gmail.com"},
+		{ID: 4, Name: "Sara", Email: "sara@hotmail.com"},
+	}
+	for i := range users {
+		err := d.Insert(&users[i]).Run()
+		assert.Nil(t, err)
+	}
+}
+
+func TestCondition_Operators(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+	insertConditionUsers(t, newDurazzo)
+
+	tests := []struct {
+		name      string
+		condition durazzo.Condition
+		expected  []string
+	}{
+		{name: "Lt", condition: durazzo.Lt("id", 2), expected: []string{"kris"}},
+		{name: "Lte", condition: durazzo.Lte("id", 2), expected: []string{"kris", "erald"}},
+		{name: "Gt", condition: durazzo.Gt("id", 3), expected: []string{"Sara"}},
+		{name: "Gte", condition: durazzo.Gte("id", 3), expected: []string{"jessie", "Sara"}},
+		{name: "Neq", condition: durazzo.Neq("name", "kris"), expected: []string{"erald", "jessie", "Sara"}},
+		{name: "Like", condition: durazzo.Like("email", "%@yahoo.com"), expected: []string{"kris", "erald"}},
+		{name: "ILike", condition: durazzo.ILike("name", "sara"), expected: []string{"Sara"}},
+		{name: "In", condition: durazzo.In("id", 1, 3), expected: []string{"kris", "jessie"}},
+		{name: "InSlice", condition: durazzo.In("id", []int{2, 4}), expected: []string{"erald", "Sara"}},
+		{name: "InEmpty", condition: durazzo.In("id"), expected: nil},
+		{name: "NotIn", condition: durazzo.NotIn("id", 1, 2, 3), expected: []string{"Sara"}},
+		{name: "Between", condition: durazzo.Between("id", 2, 3), expected: []string{"erald", "jessie"}},
+		{name: "IsNull", condition: durazzo.IsNull("email"), expected: nil},
+		{name: "IsNotNull", condition: durazzo.IsNotNull("email"), expected: []string{"kris", "erald", "jessie", "Sara"}},
+		{name: "Where", condition: durazzo.Where("id", ">=", 4), expected: []string{"Sara"}},
+		{
+			name:      "OrGroup",
+			condition: durazzo.Or(durazzo.Eq("name", "kris"), durazzo.And(durazzo.Gt("id", 2), durazzo.Like("email", "%@gmail.com"))),
+			expected:  []string{"kris", "jessie"},
+		},
+		{name: "Not", condition: durazzo.Not(durazzo.In("id", 1, 2)), expected: []string{"jessie", "Sara"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var users []User
+			err := newDurazzo.Select(&users).Filter(tt.condition).Run()
+			assert.Nil(t, err)
+
+			var names []string
+			for _, user := range users {
+				names = append(names, user.Name)
+			}
+			assert.Equal(t, tt.expected, names)
+		})
+	}
+}
+
+func TestCondition_InvalidOperator(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	var users []User
+	err := newDurazzo.Select(&users).Filter(durazzo.Where("id", "~", 1)).Run()
+	assert.NotNil(t, err)
+}
+
+func TestCondition_UpdateAndDelete(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+	insertConditionUsers(t, newDurazzo)
+
+	err := newDurazzo.Update("user").
+		Set("name", "hidden").
+		Filter(durazzo.In("id", 1, 2), durazzo.Like("email", "%@yahoo.com")).
+		Run()
+	assert.Nil(t, err)
+
+	var hidden []User
+	err = newDurazzo.Select(&hidden).Where("name", "hidden").Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(hidden))
+
+	err = newDurazzo.Delete("user").Filter(durazzo.Or(durazzo.Eq("id", 1), durazzo.Gt("id", 3))).Run()
+	assert.Nil(t, err)
+
+	var users []User
+	err = newDurazzo.Select(&users).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(users))
+}
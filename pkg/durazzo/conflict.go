@@ -0,0 +1,114 @@
+package durazzo
+
+import (
+	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/util"
+	"strings"
+)
+
+// OnConflictType configures what an INSERT does with rows clashing with a unique constraint
+type OnConflictType struct {
+	insert   *InsertType
+	conflict *conflictClause
+}
+
+// conflictClause is the resolved upsert of an InsertType, an empty update list means DO NOTHING
+type conflictClause struct {
+	columns   []string
+	update    []string
+	updateAll bool
+}
+
+// OnConflict turns the INSERT into an upsert on the given unique columns or Go field names.
+// MySQL ignores the columns since ON DUPLICATE KEY UPDATE reacts to every unique key of the table.
+func (it *InsertType) OnConflict(columns ...string) *OnConflictType {
+	conflict := &conflictClause{}
+	for _, column := range columns {
+		conflict.columns = append(conflict.columns, it.resolveColumn(it.modelType, column))
+	}
+	return &OnConflictType{insert: it, conflict: conflict}
+}
+
+// DoNothing skips the clashing rows
+func (oc *OnConflictType) DoNothing() *InsertType {
+	oc.insert.conflict = oc.conflict
+	return oc.insert
+}
+
+// DoUpdate overwrites the given columns of the clashing rows with the inserted values,
+// without columns every inserted column but the conflict ones and the creation time is overwritten.
+// Postgres and SQLite need the conflict columns to update a row, only MySQL accepts an OnConflict without them.
+func (oc *OnConflictType) DoUpdate(columns ...string) *InsertType {
+	if len(oc.conflict.columns) == 0 && oc.insert.dialect.Name() != Mysql {
+		oc.insert.err = fmt.Errorf("DoUpdate requires the conflict columns on %s", oc.insert.dialect.Name())
+	}
+	for _, column := range columns {
+		oc.conflict.update = append(oc.conflict.update, oc.insert.resolveColumn(oc.insert.modelType, column))
+	}
+	oc.conflict.updateAll = len(columns) == 0
+	oc.insert.conflict = oc.conflict
+	return oc.insert
+}
+
+// clause renders the conflict handling of a statement inserting columns
+func (c *conflictClause) clause(dialect Dialect, columns []*util.Field) string {
+	inserted := make([]string, 0, len(columns))
+	for _, field := range columns {
+		inserted = append(inserted, dialect.Quote(field.Column))
+	}
+	return dialect.OnConflict(quoteAll(dialect, c.columns), quoteAll(dialect, c.updateColumns(columns)), inserted)
+}
+
+// updateColumns returns the columns overwritten by the upsert of a statement inserting columns
+func (c *conflictClause) updateColumns(columns []*util.Field) []string {
+	if !c.updateAll {
+		return c.update
+	}
+	var update []string
+	for _, field := range columns {
+		// the creation time of an existing row survives the upsert
+		if !containsFold(c.columns, field.Column) && !field.IsAutoCreateTime() {
+			update = append(update, field.Column)
+		}
+	}
+	return update
+}
+
+// doNothing reports whether clashing rows of a statement inserting columns are skipped, which happens
+// as well when DoUpdate finds nothing to overwrite. Skipped rows are missing from RETURNING.
+func (c *conflictClause) doNothing(columns []*util.Field) bool {
+	return len(c.updateColumns(columns)) == 0
+}
+
+// onConflict renders the ON CONFLICT clause shared by Postgres and SQLite
+func onConflict(conflict, update []string) string {
+	target := ""
+	if len(conflict) > 0 {
+		target = " (" + strings.Join(conflict, ", ") + ")"
+	}
+	if len(update) == 0 {
+		return fmt.Sprintf("ON CONFLICT%s DO NOTHING", target)
+	}
+	assignments := make([]string, 0, len(update))
+	for _, column := range update {
+		assignments = append(assignments, fmt.Sprintf("%s = EXCLUDED.%s", column, column))
+	}
+	return fmt.Sprintf("ON CONFLICT%s DO UPDATE SET %s", target, strings.Join(assignments, ", "))
+}
+
+func quoteAll(dialect Dialect, columns []string) []string {
+	quoted := make([]string, 0, len(columns))
+	for _, column := range columns {
+		quoted = append(quoted, dialect.Quote(column))
+	}
+	return quoted
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -5,3 +5,16 @@ const (
 	Postgres = "postgres"
 	Mysql    = "mysql"
 )
+
+// Sort directions accepted by SelectType.OrderBy
+const (
+	Asc  = "ASC"
+	Desc = "DESC"
+)
+
+// Join kinds of a JoinClause
+const (
+	InnerJoin = "INNER JOIN"
+	LeftJoin  = "LEFT JOIN"
+	RightJoin = "RIGHT JOIN"
+)
@@ -1,20 +1,40 @@
 package durazzo
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"time"
 )
 
+const defaultPingTimeout = 5 * time.Second
+
 type Config struct {
 	Driver string
 	DSN    string
+
+	// NamingStrategy derives table and column names from models, defaults to lower cased names
+	NamingStrategy NamingStrategy
+
+	// PingTimeout bounds the connectivity check made by NewDurazzo, defaults to 5 seconds
+	PingTimeout time.Duration
+
+	// Connection pool settings, zero values keep the database/sql defaults
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
 }
 
-func newConnection(config Config) *sql.DB {
+func newConnection(config Config) (*sql.DB, Dialect, error) {
 	var db *sql.DB
 	var err error
 
+	dialect, err := newDialect(config.Driver)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	switch config.Driver {
 	case Sqlite:
 		db, err = initSQLite(config.DSN)
@@ -22,13 +42,40 @@ func newConnection(config Config) *sql.DB {
 		db, err = initPostgres(config.DSN)
 	case Mysql:
 		db, err = initMySQL(config.DSN)
-	default:
-		log.Fatalf("Unsupported driver: %s", config.Driver)
 	}
-
 	if err != nil {
-		log.Fatalf("Failed to connect to %s database: %v", config.Driver, err)
+		return nil, nil, fmt.Errorf("failed to open %s database: %w", config.Driver, err)
+	}
+
+	configurePool(db, config)
+
+	pingTimeout := config.PingTimeout
+	if pingTimeout <= 0 {
+		pingTimeout = defaultPingTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, nil, fmt.Errorf("failed to connect to %s database: %w", config.Driver, err)
 	}
 	fmt.Printf("Connected to %s database successfully!\n", config.Driver)
-	return db
+	return db, dialect, nil
+}
+
+// configurePool applies the pool settings that were set on the config
+func configurePool(db *sql.DB, config Config) {
+	if config.MaxOpenConns > 0 {
+		db.SetMaxOpenConns(config.MaxOpenConns)
+	}
+	if config.MaxIdleConns > 0 {
+		db.SetMaxIdleConns(config.MaxIdleConns)
+	}
+	if config.ConnMaxLifetime > 0 {
+		db.SetConnMaxLifetime(config.ConnMaxLifetime)
+	}
+	if config.ConnMaxIdleTime > 0 {
+		db.SetConnMaxIdleTime(config.ConnMaxIdleTime)
+	}
 }
@@ -4,6 +4,7 @@ import (
 	"github.com/EraldCaka/durazzo/pkg/durazzo"
 	"github.com/stretchr/testify/assert"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -13,11 +14,14 @@ func setupDatabase(t *testing.T) *durazzo.Durazzo {
 	if databaseURL == "" {
 		t.Fatalf("DATABASE_URL is not set in environment")
 	}
-	newDurazzo := durazzo.NewDurazzo(durazzo.Config{
+	newDurazzo, err := durazzo.NewDurazzo(durazzo.Config{
 		Driver: durazzo.Postgres,
 		DSN:    databaseURL,
 	})
-	err := newDurazzo.AutoMigrate(&User{}, &Post{})
+	if err != nil {
+		t.Fatalf("failed to connect to the database: %v", err)
+	}
+	err = newDurazzo.AutoMigrate(&User{}, &Post{})
 	assert.Nil(t, err)
 	return newDurazzo
 }
@@ -31,3 +35,20 @@ func tearDownDatabase(t *testing.T, d *durazzo.Durazzo) {
 	_, err = d.Db.Exec(dropTableQueryPost)
 	assert.Nil(t, err)
 }
+
+// Setup function to initialize a file backed SQLite database, it needs no external service
+func setupSQLiteDatabase(t *testing.T) *durazzo.Durazzo {
+	newDurazzo, err := durazzo.NewDurazzo(durazzo.Config{
+		Driver: durazzo.Sqlite,
+		DSN:    filepath.Join(t.TempDir(), "durazzo.db"),
+	})
+	if err != nil {
+		t.Fatalf("failed to open the sqlite database: %v", err)
+	}
+	err = newDurazzo.AutoMigrate(&User{}, &Post{})
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, newDurazzo.Close())
+	})
+	return newDurazzo
+}
@@ -1,43 +1,108 @@
 package durazzo
 
 import (
+	"context"
 	"fmt"
-	"strings"
+	"reflect"
+	"time"
 )
 
 // DeleteType handles DELETE operations
 type DeleteType struct {
 	*Durazzo
 	tableName  string
-	conditions []string
-	args       []interface{}
+	modelType  reflect.Type
+	conditions []Condition
+	softDelete softDelete
+	write
+	err error
 }
 
-// Delete initializes a DELETE operation
-func (d *Durazzo) Delete(tableName string) *DeleteType {
+// Delete initializes a DELETE operation, target is either a table name or a model.
+// With a model, Where also accepts Go field names
+func (d *Durazzo) Delete(target interface{}) *DeleteType {
+	tableName, modelType, err := d.resolveTarget(target)
+	if err != nil {
+		err = fmt.Errorf("failed to initialize DeleteType: %w", err)
+	}
+
 	return &DeleteType{
 		Durazzo:    d,
 		tableName:  tableName,
-		conditions: []string{},
-		args:       []interface{}{},
+		modelType:  modelType,
+		softDelete: d.softDelete(modelType),
+		err:        err,
+		conditions: []Condition{},
 	}
 }
 
-// Where adds a condition to the DELETE query
-func (dt *DeleteType) Where(field, value string) *DeleteType {
-	dt.conditions = append(dt.conditions, fmt.Sprintf(`%s = $%d`, field, len(dt.args)+1))
-	dt.args = append(dt.args, value)
+// Where adds an equality condition to the DELETE query
+func (dt *DeleteType) Where(field string, value interface{}) *DeleteType {
+	dt.conditions = append(dt.conditions, Eq(dt.resolveColumn(dt.modelType, field), value))
+	return dt
+}
+
+// Filter adds conditions to the DELETE query, they are joined by AND
+func (dt *DeleteType) Filter(conditions ...Condition) *DeleteType {
+	dt.conditions = append(dt.conditions, conditions...)
+	return dt
+}
+
+// ExpectRows makes the DELETE fail with ErrNoRowsAffected and roll back unless exactly n rows are deleted
+func (dt *DeleteType) ExpectRows(n int64) *DeleteType {
+	dt.expect = true
+	dt.expectRows = n
+	return dt
+}
+
+// Returning scans the deleted rows into model, a pointer to a struct or a slice, on Postgres and SQLite
+func (dt *DeleteType) Returning(model interface{}) *DeleteType {
+	dt.returning = model
 	return dt
 }
 
 // Run executes the DELETE query
 func (dt *DeleteType) Run() error {
+	return dt.RunContext(context.Background())
+}
+
+// RunContext executes the DELETE query using ctx
+func (dt *DeleteType) RunContext(ctx context.Context) error {
+	_, err := dt.ExecContext(ctx)
+	return err
+}
+
+// Exec executes the DELETE query and reports the affected rows
+func (dt *DeleteType) Exec() (Result, error) {
+	return dt.ExecContext(context.Background())
+}
+
+// ExecContext executes the DELETE query using ctx and reports the affected rows
+func (dt *DeleteType) ExecContext(ctx context.Context) (Result, error) {
+	if dt.err != nil {
+		return Result{}, dt.err
+	}
 	if len(dt.conditions) == 0 {
-		return fmt.Errorf("no conditions specified for DELETE operation")
+		return Result{}, fmt.Errorf("no conditions specified for DELETE operation")
 	}
 
-	query := fmt.Sprintf(`DELETE FROM "%s" WHERE %s`, dt.tableName, strings.Join(dt.conditions, " AND "))
-	_, err := dt.Durazzo.Db.Exec(query, dt.args...)
-	
-	return err
+	var args []interface{}
+	if dt.softDelete.active() {
+		// a soft delete stamps the rows that are not deleted yet
+		set := fmt.Sprintf(`%s = %s`, dt.dialect.Quote(dt.softDelete.column), bind(dt.dialect, &args, time.Now()))
+		where, err := buildConditions(dt.dialect, dt.softDelete.scope(dt.conditions), &args)
+		if err != nil {
+			return Result{}, err
+		}
+		query := fmt.Sprintf(`UPDATE %s SET %s WHERE %s`, quoteTable(dt.dialect, dt.tableName), set, where)
+		return dt.exec(ctx, dt.Durazzo, query, args)
+	}
+
+	where, err := buildConditions(dt.dialect, dt.conditions, &args)
+	if err != nil {
+		return Result{}, err
+	}
+
+	query := fmt.Sprintf(`DELETE FROM %s WHERE %s`, quoteTable(dt.dialect, dt.tableName), where)
+	return dt.exec(ctx, dt.Durazzo, query, args)
 }
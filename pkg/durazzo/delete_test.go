@@ -1,8 +1,11 @@
 package durazzo_test
 
 import (
+	"context"
+	"github.com/EraldCaka/durazzo/pkg/durazzo"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestDurazzo_Delete(t *testing.T) {
@@ -31,3 +34,126 @@ func TestDurazzo_Delete(t *testing.T) {
 
 	assert.Equal(t, 0, len(users))
 }
+
+func TestDurazzo_DeleteModel(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	err := newDurazzo.AutoMigrate(&User{})
+	assert.Nil(t, err)
+	users := []User{{Name: "kris", Email: "kris@gmail.com"}, {Name: "erald", Email: "erald@gmail.com"}}
+	err = newDurazzo.Insert(users).Run()
+	assert.Nil(t, err)
+
+	err = newDurazzo.DeleteModel(&users[0]).Run()
+	assert.Nil(t, err)
+
+	var stored []User
+	err = newDurazzo.Select(&stored).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []User{users[1]}, stored)
+
+	err = newDurazzo.DeleteModel(&User{}).Run()
+	assert.NotNil(t, err)
+	err = newDurazzo.DeleteModel(User{ID: 2}).Run()
+	assert.NotNil(t, err)
+}
+
+func TestDurazzo_Delete_Result(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+	insertConditionUsers(t, newDurazzo)
+
+	var deleted User
+	result, err := newDurazzo.Delete(&User{}).Where("ID", 1).Returning(&deleted).ExpectRows(1).Exec()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), result.RowsAffected)
+	assert.Equal(t, 1, deleted.ID)
+
+	result, err = newDurazzo.DeleteModel(&deleted).ExpectRows(1).Exec()
+	assert.ErrorIs(t, err, durazzo.ErrNoRowsAffected)
+	assert.Equal(t, int64(0), result.RowsAffected)
+}
+
+type Invoice struct {
+	ID        int `durazzo:"primary_key"`
+	Number    string
+	DeletedAt durazzo.DeletedAt
+}
+
+func TestDurazzo_Delete_Soft(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	err := newDurazzo.AutoMigrate(&Invoice{})
+	assert.Nil(t, err)
+	invoices := []Invoice{{Number: "A-1"}, {Number: "A-2"}, {Number: "A-3"}}
+	err = newDurazzo.Insert(invoices).Run()
+	assert.Nil(t, err)
+
+	err = newDurazzo.DeleteModel(&invoices[0]).ExpectRows(1).Run()
+	assert.Nil(t, err)
+	err = newDurazzo.DeleteModel(&invoices[0]).ExpectRows(1).Run()
+	assert.ErrorIs(t, err, durazzo.ErrNoRowsAffected, "a deleted row is not deleted twice")
+
+	var visible []Invoice
+	err = newDurazzo.Select(&visible).OrderBy("id", durazzo.Asc).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []Invoice{invoices[1], invoices[2]}, visible)
+
+	result, err := newDurazzo.Update(&Invoice{}).Set("Number", "B").Filter(durazzo.Gt("id", 0)).Exec()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), result.RowsAffected)
+
+	var all []Invoice
+	err = newDurazzo.Select(&all).Unscoped().OrderBy("id", durazzo.Asc).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(all))
+	assert.Equal(t, "A-1", all[0].Number)
+	assert.True(t, all[0].DeletedAt.Valid)
+	assert.False(t, all[1].DeletedAt.Valid)
+
+	err = newDurazzo.Delete(&Invoice{}).Where("ID", 2).HardDelete().Run()
+	assert.Nil(t, err)
+	var remaining []Invoice
+	err = newDurazzo.Select(&remaining).Unscoped().Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(remaining))
+}
+
+type Receipt struct {
+	ID      int `durazzo:"primary_key"`
+	Number  string
+	Removed time.Time `durazzo:"soft_delete"`
+}
+
+func TestDurazzo_Delete_SoftTimeField(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	plan, err := newDurazzo.AutoMigrateDryRun(context.Background(), &Receipt{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{`CREATE TABLE IF NOT EXISTS "receipt" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "number" TEXT NOT NULL, "removed" DATETIME);`}, plan)
+	err = newDurazzo.AutoMigrate(&Receipt{})
+	assert.Nil(t, err)
+	receipts := []Receipt{{Number: "R-1"}, {Number: "R-2"}}
+	err = newDurazzo.Insert(receipts).Run()
+	assert.Nil(t, err)
+
+	var visible []Receipt
+	err = newDurazzo.Select(&visible).OrderBy("id", durazzo.Asc).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, receipts, visible, "the zero time is stored as NULL")
+
+	result, err := newDurazzo.DeleteModel(&receipts[0]).Exec()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), result.RowsAffected)
+	var all []Receipt
+	err = newDurazzo.Select(&all).Unscoped().OrderBy("id", durazzo.Asc).Run()
+	assert.Nil(t, err)
+	assert.False(t, all[0].Removed.IsZero())
+	assert.True(t, all[1].Removed.IsZero())
+
+	type Flagged struct {
+		ID      int  `durazzo:"primary_key"`
+		Deleted bool `durazzo:"soft_delete"`
+	}
+	err = newDurazzo.AutoMigrate(&Flagged{})
+	assert.NotNil(t, err, "soft_delete needs a time field")
+}
@@ -0,0 +1,161 @@
+package durazzo
+
+import (
+	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/util"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// Dialect hides the SQL differences between the supported drivers so that the
+// same model code can run unchanged on Postgres, MySQL and SQLite
+type Dialect interface {
+	// Name returns the driver name the dialect belongs to
+	Name() string
+	// Placeholder returns the bind parameter for the n-th argument (1 based)
+	Placeholder(n int) string
+	// Quote quotes a single identifier such as a table or a column name
+	Quote(identifier string) string
+	// DataType maps a column to its SQL type
+	DataType(column Column) string
+	// AutoIncrement returns the full type definition of an auto-increment primary key
+	AutoIncrement(column Column) string
+	// CaseInsensitiveLike renders a LIKE comparison that ignores case
+	CaseInsensitiveLike(column, placeholder string) string
+	// Returning reports whether INSERT ... RETURNING hands back the generated columns
+	Returning() bool
+	// OnConflict renders the upsert clause of an INSERT from quoted columns,
+	// clashing rows are skipped when update is empty
+	OnConflict(conflict, update, inserted []string) string
+	// MaxParameters is the number of bind parameters a single statement may carry
+	MaxParameters() int
+	// LimitOffset renders the LIMIT/OFFSET clause, a zero limit means no limit
+	LimitOffset(limit, offset int) string
+	// TransactionalDDL reports whether schema changes can be rolled back as part of a transaction
+	TransactionalDDL() bool
+	// MigrationLock returns the statements taking and releasing a session level lock named name,
+	// both are empty when the database serialises writers by itself
+	MigrationLock(name string) (lock, unlock string, args []interface{})
+	// TableExistsQuery returns a query counting the tables named table in schema,
+	// an empty schema stands for the current one
+	TableExistsQuery(schema, table string) (string, []interface{})
+	// ColumnsQuery returns a query listing the column names of a table
+	ColumnsQuery(schema, table string) (string, []interface{})
+	// IndexesQuery returns a query listing the index names of a table
+	IndexesQuery(schema, table string) (string, []interface{})
+}
+
+// Column describes a model field as seen by a Dialect when generating DDL,
+// the Type of a nullable field is the type of the value it wraps
+type Column struct {
+	Name       string
+	Type       reflect.Type
+	Size       int
+	Precision  int
+	Scale      int
+	PrimaryKey bool
+	Unique     bool
+	// JSON is set for fields stored with the json serializer
+	JSON bool
+	// Indexed is set for columns covered by a secondary index or referencing another table
+	Indexed bool
+}
+
+// newDialect selects the Dialect matching the configured driver
+func newDialect(driver string) (Dialect, error) {
+	switch driver {
+	case Postgres:
+		return &postgresDialect{}, nil
+	case Mysql:
+		return &mysqlDialect{}, nil
+	case Sqlite:
+		return &sqliteDialect{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported driver: %s", driver)
+	}
+}
+
+var simpleIdentifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*)*$`)
+
+var aliasRegex = regexp.MustCompile(`^(.+)\s+(?i:as)\s+([a-zA-Z_][a-zA-Z0-9_]*)$`)
+
+var aggregateRegex = regexp.MustCompile(`^((?i:count|sum|avg|min|max))\(\s*((?i:distinct)\s+)?([a-zA-Z_][a-zA-Z0-9_.]*)\s*\)$`)
+
+// quoteColumn quotes plain and table-qualified column references and the column of an aggregate
+// such as SUM(amount), optionally aliased with AS. Anything else (expressions, functions, *) is
+// passed through untouched
+func quoteColumn(dialect Dialect, column string) string {
+	if alias := aliasRegex.FindStringSubmatch(column); alias != nil {
+		return quoteColumn(dialect, strings.TrimSpace(alias[1])) + " AS " + dialect.Quote(alias[2])
+	}
+	if aggregate := aggregateRegex.FindStringSubmatch(column); aggregate != nil && simpleIdentifierRegex.MatchString(aggregate[3]) {
+		return fmt.Sprintf("%s(%s%s)", strings.ToUpper(aggregate[1]), strings.ToUpper(aggregate[2]), quoteColumn(dialect, aggregate[3]))
+	}
+	if !simpleIdentifierRegex.MatchString(column) {
+		return column
+	}
+	parts := strings.Split(column, ".")
+	for i, part := range parts {
+		parts[i] = dialect.Quote(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// quoteTable quotes a table name that may be qualified by its schema
+func quoteTable(dialect Dialect, tableName string) string {
+	parts := strings.Split(tableName, ".")
+	for i, part := range parts {
+		parts[i] = dialect.Quote(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// splitTableName separates the schema from a qualified table name
+func splitTableName(tableName string) (string, string) {
+	if i := strings.LastIndex(tableName, "."); i >= 0 {
+		return tableName[:i], tableName[i+1:]
+	}
+	return "", tableName
+}
+
+// baseDataType holds the type mapping shared by every dialect
+func baseDataType(column Column) string {
+	if util.IsTimeType(column.Type) {
+		return "TIMESTAMP"
+	}
+	switch column.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return "INTEGER"
+	case reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "BIGINT"
+	case reflect.Float32, reflect.Float64:
+		if column.Precision > 0 {
+			return fmt.Sprintf("DECIMAL(%d,%d)", column.Precision, column.Scale)
+		}
+		return "REAL"
+	case reflect.String:
+		if column.Size > 0 {
+			return fmt.Sprintf("VARCHAR(%d)", column.Size)
+		}
+		return "TEXT"
+	case reflect.Bool:
+		return "BOOLEAN"
+	default:
+		return "TEXT"
+	}
+}
+
+// limitOffset renders LIMIT/OFFSET for dialects that accept OFFSET on its own
+func limitOffset(limit, offset int) string {
+	switch {
+	case limit > 0 && offset > 0:
+		return fmt.Sprintf("LIMIT %d OFFSET %d", limit, offset)
+	case limit > 0:
+		return fmt.Sprintf("LIMIT %d", limit)
+	case offset > 0:
+		return fmt.Sprintf("OFFSET %d", offset)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,42 @@
+package durazzo_test
+
+import (
+	"github.com/EraldCaka/durazzo/pkg/durazzo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDialect_Placeholders(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	dialect := newDurazzo.Dialect()
+	assert.Equal(t, durazzo.Sqlite, dialect.Name())
+	assert.Equal(t, "?", dialect.Placeholder(2))
+	assert.Equal(t, `"user"`, dialect.Quote("user"))
+}
+
+func TestDialect_SQLite_CRUD(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	err := newDurazzo.Insert(&User{ID: 1, Name: "edgar", Email: "edgar@gmail.com"}).Run()
+	assert.Nil(t, err)
+	err = newDurazzo.Insert(&User{ID: 2, Name: "kris", Email: "kris@yahoo.com"}).Run()
+	assert.Nil(t, err)
+
+	err = newDurazzo.Update("user").Set("email", "edgar@yahoo.com").Where("name", "edgar").Run()
+	assert.Nil(t, err)
+
+	var users []User
+	err = newDurazzo.Select(&users).Where("name", "edgar").Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(users))
+	assert.Equal(t, "edgar@yahoo.com", users[0].Email)
+
+	err = newDurazzo.Delete("user").Where("name", "kris").Run()
+	assert.Nil(t, err)
+
+	var remaining []User
+	err = newDurazzo.Raw("SELECT * FROM user").Model(&remaining).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(remaining))
+}
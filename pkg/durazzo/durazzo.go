@@ -3,22 +3,49 @@ package durazzo
 import (
 	"database/sql"
 	logging "github.com/EraldCaka/durazzo/pkg/logs"
+	"github.com/EraldCaka/durazzo/pkg/util"
 	"log/slog"
 )
 
 type Durazzo struct {
-	Db  *sql.DB
-	log *slog.Logger
+	Db         *sql.DB
+	conn       executor
+	tx         *sql.Tx
+	savepoints int
+	dialect    Dialect
+	naming     NamingStrategy
+	log        *slog.Logger
 }
 
-// NewDurazzo creates a Durazzo instance
-func NewDurazzo(config Config) *Durazzo {
-	db := newConnection(config)
+// NewDurazzo creates a Durazzo instance, it fails when the database cannot be reached
+func NewDurazzo(config Config) (*Durazzo, error) {
+	db, dialect, err := newConnection(config)
+	if err != nil {
+		return nil, err
+	}
 
-	return &Durazzo{
-		Db:  db,
-		log: slog.New(logging.NewHandler(nil)).With(slog.Group("db")),
+	naming := config.NamingStrategy
+	if naming == nil {
+		naming = util.DefaultNaming
 	}
+
+	return &Durazzo{
+		Db:      db,
+		conn:    db,
+		dialect: dialect,
+		naming:  naming,
+		log:     slog.New(logging.NewHandler(nil)).With(slog.Group("db")),
+	}, nil
+}
+
+// NamingStrategy returns the strategy deriving table and column names from models
+func (d *Durazzo) NamingStrategy() NamingStrategy {
+	return d.naming
+}
+
+// Dialect returns the SQL dialect selected from the configured driver
+func (d *Durazzo) Dialect() Dialect {
+	return d.dialect
 }
 
 func (d *Durazzo) Close() error {
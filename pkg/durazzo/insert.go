@@ -1,71 +1,428 @@
 package durazzo
 
 import (
+	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"github.com/EraldCaka/durazzo/pkg/util"
-	"log"
 	"reflect"
 	"strings"
+	"time"
 )
 
 // InsertType handles INSERT operations
 type InsertType struct {
 	*Durazzo
 	tableName string
+	modelType reflect.Type
 	model     interface{}
+	conflict  *conflictClause
+	batchSize int
+	copyFrom  bool
+	err       error
 }
 
-// Insert initializes an INSERT operation
+// Insert initializes an INSERT operation, model is a struct or a slice of structs ([]T, []*T)
+// whose rows are inserted with multi-row statements
 func (d *Durazzo) Insert(model interface{}) *InsertType {
-	_, tableName, _, err := util.ResolveModelInfo(model)
+	modelType, tableName, _, err := util.ResolveModelInfo(model, d.naming)
 	if err != nil {
-		log.Fatalf("failed to initialize InsertType: %v", err)
+		err = fmt.Errorf("failed to initialize InsertType: %w", err)
 	}
 
 	return &InsertType{
 		Durazzo:   d,
 		tableName: tableName,
+		modelType: modelType,
 		model:     model,
+		err:       err,
 	}
 }
 
+// BatchSize limits the number of rows sent in a single statement, the dialect's
+// parameter limit caps it either way
+func (it *InsertType) BatchSize(size int) *InsertType {
+	if size <= 0 {
+		it.err = fmt.Errorf("batch size must be positive, got %d", size)
+	}
+	it.batchSize = size
+	return it
+}
+
+// CopyFrom streams the rows with COPY FROM STDIN, the fastest way to load many rows into Postgres.
+// COPY reports no generated values, keys and defaults left to the database are not read back.
+func (it *InsertType) CopyFrom() *InsertType {
+	if it.dialect.Name() != Postgres {
+		it.err = fmt.Errorf("COPY FROM is not supported by %s", it.dialect.Name())
+	}
+	it.copyFrom = true
+	return it
+}
+
 // Run executes the INSERT query
 func (it *InsertType) Run() error {
-	columns, values, placeholders, err := prepareInsertData(it.model)
+	return it.RunContext(context.Background())
+}
+
+// RunContext executes the INSERT query using ctx, statements of a chunked insert share a transaction.
+// Zero valued auto-increment keys and columns with a default are left to the database
+// and read back into the inserted structs, on MySQL defaults are only read back for models with a single primary key.
+func (it *InsertType) RunContext(ctx context.Context) error {
+	if it.err != nil {
+		return it.err
+	}
+	rows, fields, err := insertRows(it.model, it.naming)
 	if err != nil {
 		return err
 	}
+	if len(rows) == 0 || len(fields) == 0 {
+		return nil
+	}
+	stampInsertTimes(fields, rows, time.Now())
+	groups := groupInsertRows(fields, rows)
+
+	if it.copyFrom {
+		if it.conflict != nil {
+			return errors.New("COPY FROM cannot be combined with OnConflict")
+		}
+		return it.Transaction(ctx, func(tx *Durazzo) error {
+			for _, group := range groups {
+				if err := copyIn(ctx, tx.tx, it.tableName, group.columns, group.rows); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	type batch struct {
+		group *insertGroup
+		rows  []reflect.Value
+	}
+	var batches []batch
+	for _, group := range groups {
+		batchSize := it.dialect.MaxParameters() / len(group.columns)
+		if it.batchSize > 0 && it.batchSize < batchSize {
+			batchSize = it.batchSize
+		}
+		if it.fillsByLastInsertId(group) {
+			// auto-increment keys of a multi-row statement are not guaranteed to be consecutive
+			batchSize = 1
+		}
+		for start := 0; start < len(group.rows); start += batchSize {
+			batches = append(batches, batch{group: group, rows: group.rows[start:min(start+batchSize, len(group.rows))]})
+		}
+	}
+	if len(batches) == 1 {
+		return it.insertBatch(ctx, it.Durazzo, batches[0].group, batches[0].rows)
+	}
 
-	query := fmt.Sprintf(`INSERT INTO "%s" (%s) VALUES (%s)`, it.tableName, strings.Join(columns, ", "), strings.Join(placeholders, ", "))
-	_, err = it.Durazzo.Db.Exec(query, values...)
-	return err
+	return it.Transaction(ctx, func(tx *Durazzo) error {
+		for _, batch := range batches {
+			if err := it.insertBatch(ctx, tx, batch.group, batch.rows); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
-// prepareInsertData prepares the columns, values, and placeholders for an INSERT statement
-func prepareInsertData(model interface{}) ([]string, []interface{}, []string, error) {
-	modelValue := reflect.ValueOf(model)
-	if modelValue.Kind() == reflect.Ptr {
-		modelValue = modelValue.Elem()
+// stampInsertTimes fills the zero auto create and update time fields of the inserted structs
+func stampInsertTimes(fields []*util.Field, rows []reflect.Value, now time.Time) {
+	for _, field := range fields {
+		if !field.IsAutoCreateTime() && !field.IsAutoUpdateTime() {
+			continue
+		}
+		for _, row := range rows {
+			if value := row.FieldByIndex(field.Index); value.IsZero() {
+				util.SetTime(value, now)
+			}
+		}
+	}
+}
+
+// insertGroup holds the rows inserted with the same columns, generated are the columns left to the database
+type insertGroup struct {
+	columns   []*util.Field
+	generated []*util.Field
+	rows      []reflect.Value
+}
+
+// groupInsertRows splits rows into consecutive runs leaving the same columns to the database, so rows
+// are still inserted in order. A column is only omitted when others remain to be inserted.
+func groupInsertRows(fields []*util.Field, rows []reflect.Value) []*insertGroup {
+	var groups []*insertGroup
+	var previousKey string
+	for _, row := range rows {
+		key := make([]byte, len(fields))
+		var columns, generated []*util.Field
+		for i, field := range fields {
+			key[i] = '0'
+			if (field.IsAutoIncrement() || field.Tag.HasDefault) && row.FieldByIndex(field.Index).IsZero() {
+				key[i] = '1'
+				generated = append(generated, field)
+				continue
+			}
+			columns = append(columns, field)
+		}
+		if len(columns) == 0 {
+			key, columns, generated = []byte{}, fields, nil
+		}
+
+		if len(groups) == 0 || string(key) != previousKey {
+			groups = append(groups, &insertGroup{columns: columns, generated: generated})
+			previousKey = string(key)
+		}
+		group := groups[len(groups)-1]
+		group.rows = append(group.rows, row)
+	}
+	return groups
+}
+
+// insertBatch inserts rows with a single multi-row statement and reads back their generated columns
+func (it *InsertType) insertBatch(ctx context.Context, d *Durazzo, group *insertGroup, rows []reflect.Value) error {
+	columns, values, placeholders := prepareInsertData(group.columns, rows, it.dialect)
+	query := fmt.Sprintf(`INSERT INTO %s (%s) VALUES %s`, quoteTable(it.dialect, it.tableName), strings.Join(columns, ", "), strings.Join(placeholders, ", "))
+	if it.conflict != nil {
+		query += " " + it.conflict.clause(it.dialect, group.columns)
+	}
+
+	generated := group.generated
+	if !it.dialect.Returning() {
+		generated = it.readableGenerated(generated, rows[0].Type())
+	}
+	// skipped or updated rows of an upsert can only be matched to the structs through RETURNING
+	backFill := len(generated) > 0
+	if it.conflict != nil && (it.conflict.doNothing(group.columns) || !it.dialect.Returning()) {
+		backFill = false
+	}
+	if !backFill {
+		_, err := d.conn.ExecContext(ctx, query, values...)
+		return err
+	}
+	if it.dialect.Returning() {
+		return it.insertReturning(ctx, d, query, values, generated, rows)
 	}
 
-	if modelValue.Kind() != reflect.Struct {
-		return nil, nil, nil, errors.New("model must be a struct or a pointer to a struct")
+	result, err := d.conn.ExecContext(ctx, query, values...)
+	if err != nil {
+		return err
+	}
+	return it.fillGenerated(ctx, d, result, generated, rows)
+}
+
+// insertReturning runs query with a RETURNING clause and scans the generated columns into rows
+func (it *InsertType) insertReturning(ctx context.Context, d *Durazzo, query string, values []interface{}, generated []*util.Field, rows []reflect.Value) error {
+	returning := make([]string, 0, len(generated))
+	for _, field := range generated {
+		returning = append(returning, it.dialect.Quote(field.Column))
 	}
 
-	var columns []string
-	var values []interface{}
-	var placeholders []string
+	result, err := d.conn.QueryContext(ctx, query+" RETURNING "+strings.Join(returning, ", "), values...)
+	if err != nil {
+		return err
+	}
+	defer func(result *sql.Rows) {
+		_ = result.Close()
+	}(result)
 
-	for i := 0; i < modelValue.NumField(); i++ {
-		field := modelValue.Field(i)
-		if !field.CanInterface() {
+	targets := make([]interface{}, len(generated))
+	for i := 0; result.Next(); i++ {
+		if i >= len(rows) {
+			return errors.New("INSERT returned more rows than it inserted")
+		}
+		for j, field := range generated {
+			targets[j] = field.ScanTarget(rows[i])
+		}
+		if err := result.Scan(targets...); err != nil {
+			return err
+		}
+	}
+	return result.Err()
+}
+
+// fillsByLastInsertId reports whether the generated key of group is read back through LastInsertId,
+// which only identifies the row of a single-row statement
+func (it *InsertType) fillsByLastInsertId(group *insertGroup) bool {
+	if it.dialect.Returning() || it.conflict != nil {
+		return false
+	}
+	for _, field := range group.generated {
+		if field.IsAutoIncrement() {
+			return true
+		}
+	}
+	return false
+}
+
+// readableGenerated drops the defaults a database without RETURNING cannot read back. They are selected by
+// the primary key after the INSERT, the fields of a model without a single primary key keep their value.
+func (it *InsertType) readableGenerated(generated []*util.Field, structType reflect.Type) []*util.Field {
+	if _, err := singlePrimaryKey(structType, it.naming); err == nil {
+		return generated
+	}
+	var readable []*util.Field
+	for _, field := range generated {
+		if field.IsAutoIncrement() {
+			readable = append(readable, field)
+		}
+	}
+	return readable
+}
+
+// fillGenerated back-fills rows on databases without RETURNING. The auto-increment key is read from
+// LastInsertId, rows leaving it to the database are inserted one per statement for that reason.
+// Other generated columns are selected by the primary key afterwards.
+func (it *InsertType) fillGenerated(ctx context.Context, d *Durazzo, result sql.Result, generated []*util.Field, rows []reflect.Value) error {
+	var key *util.Field
+	var defaults []*util.Field
+	for _, field := range generated {
+		if field.IsAutoIncrement() && key == nil {
+			key = field
 			continue
 		}
-		columns = append(columns, modelValue.Type().Field(i).Name)
-		values = append(values, field.Interface())
-		placeholders = append(placeholders, fmt.Sprintf("$%d", len(placeholders)+1))
+		defaults = append(defaults, field)
+	}
+
+	if key != nil {
+		if len(rows) != 1 {
+			return fmt.Errorf("cannot read back the keys of a %d row INSERT", len(rows))
+		}
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to read the generated key: %w", err)
+		}
+		if err := setInteger(rows[0].FieldByIndex(key.Index), id); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key.Name, err)
+		}
+	}
+	if len(defaults) == 0 {
+		return nil
+	}
+
+	primaryKey, err := singlePrimaryKey(rows[0].Type(), it.naming)
+	if err != nil {
+		return fmt.Errorf("cannot read back the defaults of %s: %w", it.tableName, err)
+	}
+	for _, row := range rows {
+		columns := make([]string, 0, len(defaults))
+		targets := make([]interface{}, 0, len(defaults))
+		for _, field := range defaults {
+			columns = append(columns, it.dialect.Quote(field.Column))
+			targets = append(targets, field.ScanTarget(row))
+		}
+		query := fmt.Sprintf(`SELECT %s FROM %s WHERE %s = %s`, strings.Join(columns, ", "),
+			quoteTable(it.dialect, it.tableName), it.dialect.Quote(primaryKey.Column), it.dialect.Placeholder(1))
+		if err := d.conn.QueryRowContext(ctx, query, row.FieldByIndex(primaryKey.Index).Interface()).Scan(targets...); err != nil {
+			return fmt.Errorf("failed to read back the defaults of %s: %w", it.tableName, err)
+		}
 	}
+	return nil
+}
 
-	return columns, values, placeholders, nil
+// singlePrimaryKey returns the primary key field of a model with exactly one
+func singlePrimaryKey(structType reflect.Type, naming NamingStrategy) (*util.Field, error) {
+	fields, err := util.ParseFields(structType, naming)
+	if err != nil {
+		return nil, err
+	}
+	var primaryKey *util.Field
+	for _, field := range fields {
+		if field.Tag.PrimaryKey {
+			if primaryKey != nil {
+				return nil, fmt.Errorf("%s has a composite primary key", structType.Name())
+			}
+			primaryKey = field
+		}
+	}
+	if primaryKey == nil {
+		return nil, fmt.Errorf("%s has no primary key", structType.Name())
+	}
+	return primaryKey, nil
+}
+
+// setInteger stores n in an integer field of any size
+func setInteger(field reflect.Value, n int64) error {
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(uint64(n))
+	default:
+		return fmt.Errorf("cannot store a generated key in %s", field.Type())
+	}
+	return nil
+}
+
+// insertRows returns the structs held by model together with their column fields
+func insertRows(model interface{}, naming NamingStrategy) ([]reflect.Value, []*util.Field, error) {
+	modelValue := reflect.ValueOf(model)
+	for modelValue.Kind() == reflect.Ptr {
+		if modelValue.IsNil() {
+			return nil, nil, errors.New("model cannot be a nil pointer")
+		}
+		modelValue = modelValue.Elem()
+	}
+
+	var rows []reflect.Value
+	switch modelValue.Kind() {
+	case reflect.Struct:
+		rows = append(rows, settable(modelValue))
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < modelValue.Len(); i++ {
+			row := modelValue.Index(i)
+			if row.Kind() == reflect.Ptr {
+				if row.IsNil() {
+					return nil, nil, fmt.Errorf("element %d of the slice is nil", i)
+				}
+				row = row.Elem()
+			}
+			if row.Kind() != reflect.Struct {
+				return nil, nil, errors.New("model must be a struct or a slice of structs")
+			}
+			rows = append(rows, settable(row))
+		}
+	default:
+		return nil, nil, errors.New("model must be a struct or a slice of structs")
+	}
+	if len(rows) == 0 {
+		return nil, nil, nil
+	}
+
+	fields, err := util.ParseFields(rows[0].Type(), naming)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rows, fields, nil
+}
+
+// settable copies structs passed by value so that generated values can be filled in
+func settable(row reflect.Value) reflect.Value {
+	if row.CanSet() {
+		return row
+	}
+	copied := reflect.New(row.Type()).Elem()
+	copied.Set(row)
+	return copied
+}
+
+// prepareInsertData prepares the columns, values, and one placeholder group per row for an INSERT statement
+func prepareInsertData(fields []*util.Field, rows []reflect.Value, dialect Dialect) ([]string, []interface{}, []string) {
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		columns = append(columns, dialect.Quote(field.Column))
+	}
+
+	values := make([]interface{}, 0, len(fields)*len(rows))
+	placeholders := make([]string, 0, len(rows))
+	for _, row := range rows {
+		group := make([]string, 0, len(fields))
+		for _, field := range fields {
+			values = append(values, field.ColumnValue(row))
+			group = append(group, dialect.Placeholder(len(values)))
+		}
+		placeholders = append(placeholders, "("+strings.Join(group, ", ")+")")
+	}
+	return columns, values, placeholders
 }
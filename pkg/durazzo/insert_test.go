@@ -1,6 +1,8 @@
 package durazzo_test
 
 import (
+	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/durazzo"
 	"github.com/stretchr/testify/assert"
 	"testing"
 )
@@ -30,3 +32,152 @@ func TestDurazzo_Insert(t *testing.T) {
 	assert.Equal(t, "edgar", users[0].Name)
 	assert.Equal(t, "edgar@gmail.com", users[0].Email)
 }
+
+func TestDurazzo_Insert_Slice(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	err := newDurazzo.AutoMigrate(&User{})
+	assert.Nil(t, err)
+
+	users := make([]User, 0, 25)
+	for i := 1; i <= 25; i++ {
+		users = append(users, User{ID: i, Name: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@gmail.com", i)})
+	}
+	err = newDurazzo.Insert(users).BatchSize(10).Run()
+	assert.Nil(t, err)
+
+	err = newDurazzo.Insert([]*User{{ID: 26, Name: "kris", Email: "kris@gmail.com"}}).Run()
+	assert.Nil(t, err)
+
+	var count int
+	err = newDurazzo.Raw(`SELECT COUNT(*) FROM user`).Model(&count).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 26, count)
+
+	err = newDurazzo.Insert([]User{}).Run()
+	assert.Nil(t, err)
+}
+
+func TestDurazzo_Insert_SliceRollsBackFailedBatch(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	err := newDurazzo.AutoMigrate(&User{})
+	assert.Nil(t, err)
+
+	users := []User{
+		{ID: 1, Name: "kris", Email: "kris@gmail.com"},
+		{ID: 2, Name: "erald", Email: "erald@gmail.com"},
+		{ID: 3, Name: "jessie", Email: "kris@gmail.com"},
+	}
+	err = newDurazzo.Insert(users).BatchSize(2).Run()
+	assert.NotNil(t, err)
+
+	var count int
+	err = newDurazzo.Raw(`SELECT COUNT(*) FROM user`).Model(&count).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+
+	err = newDurazzo.Insert(users).CopyFrom().Run()
+	assert.NotNil(t, err, "COPY FROM is Postgres only")
+}
+
+func TestDurazzo_Insert_BackFillsGeneratedColumns(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	err := newDurazzo.AutoMigrate(&User{}, &Account{})
+	assert.Nil(t, err)
+
+	user := User{Name: "kris", Email: "kris@gmail.com"}
+	err = newDurazzo.Insert(&user).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, user.ID)
+
+	users := []User{
+		{Name: "erald", Email: "erald@gmail.com"},
+		{ID: 10, Name: "jessie", Email: "jessie@gmail.com"},
+		{Name: "sara", Email: "sara@gmail.com"},
+	}
+	err = newDurazzo.Insert(users).BatchSize(1).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 10, 11}, []int{users[0].ID, users[1].ID, users[2].ID})
+
+	accounts := []*Account{{Email: "kris@yahoo.com"}, {Email: "erald@yahoo.com", Status: "blocked"}}
+	err = newDurazzo.Insert(accounts).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, Account{ID: 1, Email: "kris@yahoo.com", Status: "active"}, *accounts[0])
+	assert.Equal(t, Account{ID: 2, Email: "erald@yahoo.com", Status: "blocked"}, *accounts[1])
+}
+
+func TestDurazzo_Insert_OnConflict(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	err := newDurazzo.AutoMigrate(&User{})
+	assert.Nil(t, err)
+
+	err = newDurazzo.Insert([]User{{Name: "kris", Email: "kris@gmail.com"}, {Name: "erald", Email: "erald@gmail.com"}}).Run()
+	assert.Nil(t, err)
+
+	err = newDurazzo.Insert(&User{Name: "kristi", Email: "kris@gmail.com"}).OnConflict("Email").DoNothing().Run()
+	assert.Nil(t, err)
+
+	users := []User{{Name: "erald caka", Email: "erald@gmail.com"}, {Name: "jessie", Email: "jessie@gmail.com"}}
+	err = newDurazzo.Insert(users).OnConflict("email").DoUpdate().Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, users[0].ID, "the updated row keeps its key")
+	assert.NotZero(t, users[1].ID)
+
+	err = newDurazzo.Insert(&User{ID: 1, Name: "sara", Email: "sara@gmail.com"}).OnConflict("ID").DoUpdate("Name").Run()
+	assert.Nil(t, err)
+	err = newDurazzo.Insert(&User{ID: 1, Name: "kristi", Email: "sara@gmail.com"}).OnConflict().DoUpdate("Name").Run()
+	assert.NotNil(t, err, "SQLite cannot update a row without the conflict target")
+	err = newDurazzo.Insert(&User{ID: 1, Name: "kristi", Email: "sara@gmail.com"}).OnConflict().DoNothing().Run()
+	assert.Nil(t, err)
+
+	var stored []User
+	err = newDurazzo.Select(&stored).OrderBy("id", durazzo.Asc).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []User{
+		{ID: 1, Name: "sara", Email: "kris@gmail.com"},
+		{ID: 2, Name: "erald caka", Email: "erald@gmail.com"},
+		{ID: users[1].ID, Name: "jessie", Email: "jessie@gmail.com"},
+	}, stored)
+}
+
+func TestDurazzo_Insert_OnConflictNothingToUpdate(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	type Label struct {
+		ID   int    `durazzo:"primary_key"`
+		Name string `durazzo:"unique"`
+	}
+	err := newDurazzo.AutoMigrate(&Label{})
+	assert.Nil(t, err)
+	err = newDurazzo.Insert(&Label{Name: "go"}).Run()
+	assert.Nil(t, err)
+
+	labels := []Label{{Name: "go"}, {Name: "sql"}}
+	err = newDurazzo.Insert(labels).OnConflict("Name").DoUpdate().Run()
+	assert.Nil(t, err, "every inserted column is a conflict column, the upsert skips clashing rows")
+	assert.Zero(t, labels[0].ID, "skipped rows are not back-filled")
+
+	var stored []Label
+	err = newDurazzo.Select(&stored).OrderBy("id", durazzo.Asc).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(stored))
+}
+
+func TestDurazzo_Insert_CopyFrom(t *testing.T) {
+	newDurazzo := setupDatabase(t)
+	defer tearDownDatabase(t, newDurazzo)
+
+	users := []User{{Name: "kris", Email: "kris@gmail.com"}, {Name: "erald", Email: "erald@gmail.com"}}
+	err := newDurazzo.Insert(users).CopyFrom().Run()
+	assert.Nil(t, err)
+	assert.Zero(t, users[0].ID, "COPY does not read generated keys back")
+
+	var stored []User
+	err = newDurazzo.Select(&stored).OrderBy("id", durazzo.Asc).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(stored))
+	assert.NotZero(t, stored[0].ID)
+}
@@ -0,0 +1,54 @@
+package durazzo
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// tableExists reports whether tableName is already present in the database
+func (d *Durazzo) tableExists(ctx context.Context, tableName string) (bool, error) {
+	schema, table := splitTableName(tableName)
+	query, args := d.dialect.TableExistsQuery(schema, table)
+
+	var count int
+	if err := d.conn.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// existingColumns returns the lower cased column names of a live table
+func (d *Durazzo) existingColumns(ctx context.Context, tableName string) (map[string]bool, error) {
+	schema, table := splitTableName(tableName)
+	query, args := d.dialect.ColumnsQuery(schema, table)
+	return d.queryNames(ctx, query, args)
+}
+
+// existingIndexes returns the lower cased index names of a live table
+func (d *Durazzo) existingIndexes(ctx context.Context, tableName string) (map[string]bool, error) {
+	schema, table := splitTableName(tableName)
+	query, args := d.dialect.IndexesQuery(schema, table)
+	return d.queryNames(ctx, query, args)
+}
+
+// queryNames collects the single text column returned by an introspection query
+func (d *Durazzo) queryNames(ctx context.Context, query string, args []interface{}) (map[string]bool, error) {
+	rows, err := d.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	names := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names[strings.ToLower(name)] = true
+	}
+	return names, rows.Err()
+}
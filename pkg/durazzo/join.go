@@ -0,0 +1,159 @@
+package durazzo
+
+import (
+	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/util"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// JoinClause is a table joined to the SELECT, Table may be followed by an alias, e.g. "author a".
+// The ? placeholders of On are bound to Args, the On of a SelectQuery is already rendered.
+type JoinClause struct {
+	Kind  string
+	Table string
+	On    string
+	Args  []interface{}
+}
+
+// Join adds an INNER JOIN of table on the given condition, its table qualified column references are
+// quoted and its ? placeholders bound to args, e.g. Join("author", "author.id = book.authorid AND author.active = ?", true)
+func (st *SelectType) Join(table, on string, args ...interface{}) *SelectType {
+	st.joins = append(st.joins, JoinClause{Kind: InnerJoin, Table: table, On: on, Args: args})
+	return st
+}
+
+// LeftJoin adds a LEFT JOIN of table, the fields scanned from it should be nullable
+func (st *SelectType) LeftJoin(table, on string, args ...interface{}) *SelectType {
+	st.joins = append(st.joins, JoinClause{Kind: LeftJoin, Table: table, On: on, Args: args})
+	return st
+}
+
+// RightJoin adds a RIGHT JOIN of table, SQLite supports it since 3.39
+func (st *SelectType) RightJoin(table, on string, args ...interface{}) *SelectType {
+	st.joins = append(st.joins, JoinClause{Kind: RightJoin, Table: table, On: on, Args: args})
+	return st
+}
+
+// tableAlias splits a joined table from its alias, the alias is the name its columns are qualified with
+func (j JoinClause) tableAlias() (string, string) {
+	parts := strings.Fields(j.Table)
+	switch {
+	case len(parts) == 3 && strings.EqualFold(parts[1], "as"):
+		return parts[0], parts[2]
+	case len(parts) == 2:
+		return parts[0], parts[1]
+	default:
+		return j.Table, j.Table
+	}
+}
+
+// bind renders the condition of the join, appending its arguments to args
+func (j JoinClause) bind(dialect Dialect, args *[]interface{}) (JoinClause, error) {
+	on, err := renderExpression(dialect, j.On, j.Args, args)
+	if err != nil {
+		return JoinClause{}, fmt.Errorf("invalid condition of the join of %s: %w", j.Table, err)
+	}
+	return JoinClause{Kind: j.Kind, Table: j.Table, On: on}, nil
+}
+
+func (j JoinClause) render(dialect Dialect) string {
+	table, alias := j.tableAlias()
+	source := quoteTable(dialect, table)
+	if alias != table {
+		source += " " + dialect.Quote(alias)
+	}
+	return fmt.Sprintf("%s %s ON %s", j.Kind, source, j.On)
+}
+
+var qualifiedColumnRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*(\.[a-zA-Z_][a-zA-Z0-9_]*){1,2}$`)
+
+// renderExpression quotes the plain table.column (or schema.table.column) tokens of a SQL expression
+// and binds its ? placeholders to values. String literals, quoted identifiers, numbers and qualified
+// function names are copied as they are.
+func renderExpression(dialect Dialect, expression string, values []interface{}, args *[]interface{}) (string, error) {
+	var rendered strings.Builder
+	bound := 0
+	for i := 0; i < len(expression); {
+		c := expression[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			// a doubled quote inside the token closes and reopens it, which copies it unchanged
+			end := strings.IndexByte(expression[i+1:], c)
+			if end < 0 {
+				return "", fmt.Errorf("unterminated %c in %q", c, expression)
+			}
+			rendered.WriteString(expression[i : i+end+2])
+			i += end + 2
+		case c == '?':
+			if bound >= len(values) {
+				return "", fmt.Errorf("%q has more placeholders than the %d arguments", expression, len(values))
+			}
+			rendered.WriteString(bind(dialect, args, values[bound]))
+			bound++
+			i++
+		case isWordByte(c):
+			end := i
+			for end < len(expression) && (isWordByte(expression[end]) || expression[end] == '.') {
+				end++
+			}
+			token := expression[i:end]
+			if qualifiedColumnRegex.MatchString(token) && (end == len(expression) || expression[end] != '(') {
+				token = quoteColumn(dialect, token)
+			}
+			rendered.WriteString(token)
+			i = end
+		default:
+			rendered.WriteByte(c)
+			i++
+		}
+	}
+	if bound != len(values) {
+		return "", fmt.Errorf("%q has %d placeholders for %d arguments", expression, bound, len(values))
+	}
+	return rendered.String(), nil
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// joinedColumns selects the columns of modelType from the joined tables. Fields of a struct embedded
+// with an embedded_prefix are read from the joined table of that struct and aliased with the prefix,
+// the other fields from the main table.
+func (st *SelectType) joinedColumns() ([]string, error) {
+	fields, err := util.ParseFields(st.modelType, st.naming)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field.Source == nil {
+			columns = append(columns, st.tableName+"."+field.Column)
+			continue
+		}
+		table, err := st.joinedTable(field.Source)
+		if err != nil {
+			return nil, err
+		}
+		column := strings.TrimPrefix(field.Column, field.Prefix)
+		columns = append(columns, fmt.Sprintf("%s.%s AS %s", table, column, field.Column))
+	}
+	return columns, nil
+}
+
+// joinedTable returns the name the columns of a struct type are qualified with in the query
+func (st *SelectType) joinedTable(structType reflect.Type) (string, error) {
+	tableName := util.TableNameOf(structType, st.naming)
+	for _, join := range st.joins {
+		if table, alias := join.tableAlias(); strings.EqualFold(table, tableName) {
+			return alias, nil
+		}
+	}
+	if strings.EqualFold(tableName, st.tableName) {
+		return st.tableName, nil
+	}
+	return "", fmt.Errorf("%s is embedded in %s but its table %s is not joined", structType.Name(), st.modelType.Name(), tableName)
+}
@@ -0,0 +1,386 @@
+package durazzo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	migrationsTable   = "schema_migrations"
+	migrationLockName = "durazzo_schema_migrations"
+)
+
+// Migration is a versioned schema change, it is either written in Go with Up/Down or in SQL with UpSQL/DownSQL
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(ctx context.Context, tx *Durazzo) error
+	Down    func(ctx context.Context, tx *Durazzo) error
+	UpSQL   string
+	DownSQL string
+}
+
+// MigrationStatus tells whether a migration has been applied and when
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+}
+
+// Migrator applies and reverts migrations, the applied versions are kept in the schema_migrations table
+type Migrator struct {
+	durazzo    *Durazzo
+	migrations map[int64]*Migration
+	err        error
+}
+
+// Migrator initializes a migrator holding migrations
+func (d *Durazzo) Migrator(migrations ...*Migration) *Migrator {
+	m := &Migrator{durazzo: d, migrations: map[int64]*Migration{}}
+	return m.Add(migrations...)
+}
+
+// Add registers migrations, versions must be unique
+func (m *Migrator) Add(migrations ...*Migration) *Migrator {
+	for _, migration := range migrations {
+		if m.err != nil {
+			return m
+		}
+		if migration.Up == nil && migration.UpSQL == "" {
+			m.err = fmt.Errorf("migration %d has no up step", migration.Version)
+			return m
+		}
+		if _, exists := m.migrations[migration.Version]; exists {
+			m.err = fmt.Errorf("migration %d is registered twice", migration.Version)
+			return m
+		}
+		m.migrations[migration.Version] = migration
+	}
+	return m
+}
+
+var migrationFileRegex = regexp.MustCompile(`^(\d+)_(.+)\.(up|down)\.sql$`)
+
+// AddFS registers the migrations stored in dir as <version>_<name>.up.sql and <version>_<name>.down.sql files,
+// e.g. an embed.FS. On MySQL files holding several statements need multiStatements=true in the DSN
+func (m *Migrator) AddFS(fsys fs.FS, dir string) *Migrator {
+	if m.err != nil {
+		return m
+	}
+
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		m.err = fmt.Errorf("failed to read migrations from %s: %w", dir, err)
+		return m
+	}
+
+	found := map[int64]*Migration{}
+	for _, entry := range entries {
+		matches := migrationFileRegex.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || matches == nil {
+			continue
+		}
+
+		version, err := strconv.ParseInt(matches[1], 10, 64)
+		if err != nil {
+			m.err = fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+			return m
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			m.err = fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+			return m
+		}
+
+		migration, ok := found[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: matches[2]}
+			found[version] = migration
+		}
+		if matches[3] == "up" {
+			migration.UpSQL = string(content)
+		} else {
+			migration.DownSQL = string(content)
+		}
+	}
+
+	for _, migration := range sortedMigrations(found) {
+		m.Add(migration)
+	}
+	return m
+}
+
+// Migrate applies every pending migration in version order
+func (m *Migrator) Migrate(ctx context.Context) error {
+	return m.locked(ctx, func(conn *Durazzo, applied map[int64]MigrationStatus) error {
+		for _, migration := range sortedMigrations(m.migrations) {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Rollback reverts the most recently applied migration
+func (m *Migrator) Rollback(ctx context.Context) error {
+	return m.locked(ctx, func(conn *Durazzo, applied map[int64]MigrationStatus) error {
+		var latest int64
+		found := false
+		for version := range applied {
+			if !found || version > latest {
+				latest, found = version, true
+			}
+		}
+		if !found {
+			return nil
+		}
+		return m.revert(ctx, conn, latest)
+	})
+}
+
+// To migrates up or down until version is the latest applied migration, version 0 reverts everything
+func (m *Migrator) To(ctx context.Context, version int64) error {
+	return m.locked(ctx, func(conn *Durazzo, applied map[int64]MigrationStatus) error {
+		if _, ok := m.migrations[version]; !ok && version != 0 {
+			return fmt.Errorf("unknown migration version %d", version)
+		}
+
+		var appliedVersions []int64
+		for appliedVersion := range applied {
+			appliedVersions = append(appliedVersions, appliedVersion)
+		}
+		sort.Slice(appliedVersions, func(i, j int) bool { return appliedVersions[i] > appliedVersions[j] })
+		for _, appliedVersion := range appliedVersions {
+			if appliedVersion <= version {
+				break
+			}
+			if err := m.revert(ctx, conn, appliedVersion); err != nil {
+				return err
+			}
+		}
+
+		for _, migration := range sortedMigrations(m.migrations) {
+			if migration.Version > version {
+				break
+			}
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.apply(ctx, conn, migration); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Status lists the registered migrations and the applied ones missing from the migrator, by version
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var statuses []MigrationStatus
+	err := m.locked(ctx, func(_ *Durazzo, applied map[int64]MigrationStatus) error {
+		for _, migration := range m.migrations {
+			status, ok := applied[migration.Version]
+			if !ok {
+				status = MigrationStatus{Version: migration.Version}
+			}
+			status.Name = migration.Name
+			statuses = append(statuses, status)
+		}
+		for version, status := range applied {
+			if _, ok := m.migrations[version]; !ok {
+				statuses = append(statuses, status)
+			}
+		}
+		return nil
+	})
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+	return statuses, err
+}
+
+// locked runs fn on a dedicated connection holding the migration lock, once the bookkeeping table exists
+func (m *Migrator) locked(ctx context.Context, fn func(conn *Durazzo, applied map[int64]MigrationStatus) error) (err error) {
+	if m.err != nil {
+		return m.err
+	}
+
+	sqlConn, err := m.durazzo.Db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire a migration connection: %w", err)
+	}
+	defer func() {
+		_ = sqlConn.Close()
+	}()
+
+	conn := *m.durazzo
+	conn.conn = sqlConn
+	conn.tx = nil
+	conn.savepoints = 0
+
+	dialect := m.durazzo.dialect
+	lock, unlock, lockArgs := dialect.MigrationLock(migrationLockName)
+	if lock != "" {
+		if _, err := sqlConn.ExecContext(ctx, lock, lockArgs...); err != nil {
+			return fmt.Errorf("failed to acquire the migration lock: %w", err)
+		}
+		defer func() {
+			// the lock has to be released even when ctx is already done
+			if _, unlockErr := sqlConn.ExecContext(context.Background(), unlock, lockArgs...); unlockErr != nil && err == nil {
+				err = fmt.Errorf("failed to release the migration lock: %w", unlockErr)
+			}
+		}()
+	}
+
+	createQuery := fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (%s BIGINT PRIMARY KEY, %s VARCHAR(255) NOT NULL, %s TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)`,
+		dialect.Quote(migrationsTable), dialect.Quote("version"), dialect.Quote("name"), dialect.Quote("applied_at"),
+	)
+	if _, err := sqlConn.ExecContext(ctx, createQuery); err != nil {
+		return fmt.Errorf("failed to create %s: %w", migrationsTable, err)
+	}
+
+	applied, err := m.applied(ctx, &conn)
+	if err != nil {
+		return err
+	}
+	return fn(&conn, applied)
+}
+
+// applied loads the rows of the bookkeeping table
+func (m *Migrator) applied(ctx context.Context, conn *Durazzo) (map[int64]MigrationStatus, error) {
+	dialect := m.durazzo.dialect
+	query := fmt.Sprintf(`SELECT %s, %s, %s FROM %s`,
+		dialect.Quote("version"), dialect.Quote("name"), dialect.Quote("applied_at"), dialect.Quote(migrationsTable))
+	rows, err := conn.conn.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", migrationsTable, err)
+	}
+	defer func(rows *sql.Rows) {
+		_ = rows.Close()
+	}(rows)
+
+	applied := map[int64]MigrationStatus{}
+	for rows.Next() {
+		var status MigrationStatus
+		var appliedAt interface{}
+		if err := rows.Scan(&status.Version, &status.Name, &appliedAt); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", migrationsTable, err)
+		}
+		status.Applied = true
+		status.AppliedAt = parseTimestamp(appliedAt)
+		applied[status.Version] = status
+	}
+	return applied, rows.Err()
+}
+
+// apply runs the up step of a migration and records it
+func (m *Migrator) apply(ctx context.Context, conn *Durazzo, migration *Migration) error {
+	dialect := m.durazzo.dialect
+	err := m.step(ctx, conn, func(tx *Durazzo) error {
+		if migration.Up != nil {
+			if err := migration.Up(ctx, tx); err != nil {
+				return err
+			}
+		} else if _, err := tx.conn.ExecContext(ctx, migration.UpSQL); err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf(`INSERT INTO %s (%s, %s) VALUES (%s, %s)`,
+			dialect.Quote(migrationsTable), dialect.Quote("version"), dialect.Quote("name"),
+			dialect.Placeholder(1), dialect.Placeholder(2))
+		_, err := tx.conn.ExecContext(ctx, query, migration.Version, migration.Name)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// revert runs the down step of an applied migration and forgets it
+func (m *Migrator) revert(ctx context.Context, conn *Durazzo, version int64) error {
+	migration, ok := m.migrations[version]
+	if !ok {
+		return fmt.Errorf("applied migration %d is not registered", version)
+	}
+	if migration.Down == nil && migration.DownSQL == "" {
+		return fmt.Errorf("migration %d %s has no down step", migration.Version, migration.Name)
+	}
+
+	dialect := m.durazzo.dialect
+	err := m.step(ctx, conn, func(tx *Durazzo) error {
+		if migration.Down != nil {
+			if err := migration.Down(ctx, tx); err != nil {
+				return err
+			}
+		} else if _, err := tx.conn.ExecContext(ctx, migration.DownSQL); err != nil {
+			return err
+		}
+
+		query := fmt.Sprintf(`DELETE FROM %s WHERE %s = %s`,
+			dialect.Quote(migrationsTable), dialect.Quote("version"), dialect.Placeholder(1))
+		_, err := tx.conn.ExecContext(ctx, query, migration.Version)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to revert migration %d %s: %w", migration.Version, migration.Name, err)
+	}
+	return nil
+}
+
+// step runs fn in its own transaction when the dialect can roll DDL back
+func (m *Migrator) step(ctx context.Context, conn *Durazzo, fn func(tx *Durazzo) error) error {
+	if m.durazzo.dialect.TransactionalDDL() {
+		return conn.Transaction(ctx, fn)
+	}
+	return fn(conn)
+}
+
+func sortedMigrations(migrations map[int64]*Migration) []*Migration {
+	sorted := make([]*Migration, 0, len(migrations))
+	for _, migration := range migrations {
+		sorted = append(sorted, migration)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Version < sorted[j].Version })
+	return sorted
+}
+
+var timestampLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02 15:04:05",
+}
+
+// parseTimestamp reads a timestamp returned by any of the drivers, MySQL returns text unless parseTime is set
+func parseTimestamp(value interface{}) time.Time {
+	var text string
+	switch v := value.(type) {
+	case time.Time:
+		return v
+	case []byte:
+		text = string(v)
+	case string:
+		text = v
+	default:
+		return time.Time{}
+	}
+
+	for _, layout := range timestampLayouts {
+		if parsed, err := time.Parse(layout, text); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
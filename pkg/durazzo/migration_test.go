@@ -0,0 +1,121 @@
+package durazzo_test
+
+import (
+	"context"
+	"errors"
+	"github.com/EraldCaka/durazzo/pkg/durazzo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"testing/fstest"
+)
+
+func migrationFiles() fstest.MapFS {
+	return fstest.MapFS{
+		"migrations/1_create_tags.up.sql":   {Data: []byte(`CREATE TABLE "tag" ("id" INTEGER PRIMARY KEY, "label" TEXT)`)},
+		"migrations/1_create_tags.down.sql": {Data: []byte(`DROP TABLE "tag"`)},
+		"migrations/3_add_color.up.sql":     {Data: []byte(`ALTER TABLE "tag" ADD COLUMN "color" TEXT`)},
+		"migrations/3_add_color.down.sql":   {Data: []byte(`ALTER TABLE "tag" DROP COLUMN "color"`)},
+		"migrations/README.md":              {Data: []byte("ignored")},
+	}
+}
+
+func TestMigrator_MigrateAndRollback(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+	ctx := context.Background()
+
+	seed := &durazzo.Migration{
+		Version: 2,
+		Name:    "seed_tags",
+		Up: func(ctx context.Context, tx *durazzo.Durazzo) error {
+			return tx.Raw(`INSERT INTO tag (id, label) VALUES (?, ?)`, 1, "go").RunContext(ctx)
+		},
+		Down: func(ctx context.Context, tx *durazzo.Durazzo) error {
+			return tx.Delete("tag").Where("id", 1).RunContext(ctx)
+		},
+	}
+	migrator := newDurazzo.Migrator(seed).AddFS(migrationFiles(), "migrations")
+
+	err := migrator.Migrate(ctx)
+	assert.Nil(t, err)
+	err = migrator.Migrate(ctx)
+	assert.Nil(t, err)
+
+	statuses, err := migrator.Status(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(statuses))
+	for i, status := range statuses {
+		assert.Equal(t, int64(i+1), status.Version)
+		assert.True(t, status.Applied)
+		assert.False(t, status.AppliedAt.IsZero())
+	}
+	assert.Equal(t, "seed_tags", statuses[1].Name)
+
+	assert.Equal(t, 1, columnCount(t, newDurazzo, "tag", "color"))
+
+	err = migrator.Rollback(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, columnCount(t, newDurazzo, "tag", "color"))
+
+	var count int
+
+	err = migrator.To(ctx, 1)
+	assert.Nil(t, err)
+	err = newDurazzo.Raw(`SELECT COUNT(*) FROM tag`).Model(&count).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, count)
+
+	err = migrator.To(ctx, 3)
+	assert.Nil(t, err)
+	statuses, err = migrator.Status(ctx)
+	assert.Nil(t, err)
+	assert.True(t, statuses[2].Applied)
+
+	err = migrator.To(ctx, 0)
+	assert.Nil(t, err)
+	exists, err := tableCount(newDurazzo, "tag")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, exists)
+}
+
+func TestMigrator_FailedMigrationRollsBack(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+	ctx := context.Background()
+
+	migrator := newDurazzo.Migrator(&durazzo.Migration{
+		Version: 1,
+		Name:    "broken",
+		Up: func(ctx context.Context, tx *durazzo.Durazzo) error {
+			if err := tx.Raw(`CREATE TABLE tag (id INTEGER)`).RunContext(ctx); err != nil {
+				return err
+			}
+			return errors.New("broken migration")
+		},
+	})
+
+	err := migrator.Migrate(ctx)
+	assert.NotNil(t, err)
+
+	exists, err := tableCount(newDurazzo, "tag")
+	assert.Nil(t, err)
+	assert.Equal(t, 0, exists)
+
+	statuses, err := migrator.Status(ctx)
+	assert.Nil(t, err)
+	assert.False(t, statuses[0].Applied)
+
+	err = newDurazzo.Migrator(&durazzo.Migration{Version: 1}).Migrate(ctx)
+	assert.NotNil(t, err)
+}
+
+func tableCount(d *durazzo.Durazzo, table string) (int, error) {
+	var count int
+	err := d.Raw(`SELECT COUNT(*) FROM sqlite_master WHERE type = ? AND name = ?`, "table", table).Model(&count).Run()
+	return count, err
+}
+
+func columnCount(t *testing.T, d *durazzo.Durazzo, table, column string) int {
+	var count int
+	err := d.Db.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	assert.Nil(t, err)
+	return count
+}
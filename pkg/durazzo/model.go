@@ -1,78 +1,486 @@
 package durazzo
 
 import (
+	"context"
 	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/util"
 	"reflect"
 	"strings"
 )
 
-// AutoMigrate creates tables based on the struct configuration for multiple models that might be inserted
+// AutoMigrate creates tables based on the struct configuration for multiple models that might be inserted,
+// tables that already exist get their missing columns and indexes added
 func (d *Durazzo) AutoMigrate(models ...interface{}) error {
+	return d.AutoMigrateContext(context.Background(), models...)
+}
+
+// AutoMigrateContext is AutoMigrate bound to ctx
+func (d *Durazzo) AutoMigrateContext(ctx context.Context, models ...interface{}) error {
+	plans, err := d.migrationPlans(ctx, models)
+	if err != nil {
+		return err
+	}
+	for _, plan := range plans {
+		for _, statement := range plan.statements {
+			if _, err := d.conn.ExecContext(ctx, statement); err != nil {
+				return fmt.Errorf("failed to migrate table for model %v: %w", plan.tableName, err)
+			}
+		}
+	}
+	return nil
+}
+
+// AutoMigrateDryRun returns the DDL AutoMigrate would execute for models without running it
+func (d *Durazzo) AutoMigrateDryRun(ctx context.Context, models ...interface{}) ([]string, error) {
+	plans, err := d.migrationPlans(ctx, models)
+	if err != nil {
+		return nil, err
+	}
+	var statements []string
+	for _, plan := range plans {
+		statements = append(statements, plan.statements...)
+	}
+	return statements, nil
+}
+
+// migrationModel is a model of an AutoMigrate call with the foreign keys its table holds
+type migrationModel struct {
+	structType  reflect.Type
+	tableName   string
+	fields      []*util.Field
+	foreignKeys []util.ForeignKey
+}
+
+// tablePlan holds the statements bringing a table up to date
+type tablePlan struct {
+	tableName  string
+	statements []string
+}
+
+// migrationPlans plans the tables of models, referenced tables come before the tables pointing to them.
+// Foreign keys of HasOne and HasMany relations are added to the associated table when it is migrated
+// in the same call, the join tables of ManyToMany relations follow every model table.
+func (d *Durazzo) migrationPlans(ctx context.Context, models []interface{}) ([]tablePlan, error) {
+	var migrations []*migrationModel
+	byTable := map[string]*migrationModel{}
 	for _, model := range models {
 		modelType := reflect.TypeOf(model)
-		if modelType.Kind() != reflect.Ptr || modelType.Elem().Kind() != reflect.Struct {
-			return fmt.Errorf("model %v must be a pointer to a struct", modelType.Name())
+		if modelType == nil || modelType.Kind() != reflect.Ptr || modelType.Elem().Kind() != reflect.Struct {
+			return nil, fmt.Errorf("model %v must be a pointer to a struct", modelType)
 		}
 
-		tableName := strings.ToLower(modelType.Elem().Name())
-		var columns []string
-
-		for i := 0; i < modelType.Elem().NumField(); i++ {
-			field := modelType.Elem().Field(i)
-			column := strings.ToLower(field.Name)
-			tag := field.Tag.Get("durazzo")
-			sqlType := determineSQLType(field.Type, tag)
+		migration := &migrationModel{structType: modelType.Elem(), tableName: util.TableNameOf(modelType.Elem(), d.naming)}
+		fields, err := util.ParseFields(migration.structType, d.naming)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse model %v: %w", migration.tableName, err)
+		}
+		migration.fields = fields
+		migration.foreignKeys, err = util.TagForeignKeys(migration.structType, d.naming)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse model %v: %w", migration.tableName, err)
+		}
+		migrations = append(migrations, migration)
+		byTable[migration.tableName] = migration
+	}
 
-			if strings.Contains(tag, "primary_key") {
-				sqlType = "SERIAL PRIMARY KEY"
+	var joinTables []*migrationModel
+	for _, migration := range migrations {
+		relations, err := util.ParseRelations(migration.structType, d.naming)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse model %v: %w", migration.tableName, err)
+		}
+		for _, relation := range relations {
+			if relation.Kind == util.ManyToMany {
+				if _, ok := byTable[relation.JoinTable]; !ok {
+					joinTable := joinTableModel(migration.structType, relation, d.naming)
+					joinTables = append(joinTables, joinTable)
+					byTable[joinTable.tableName] = joinTable
+				}
+				continue
+			}
+			table, foreignKey := relation.Constraint(migration.structType, d.naming)
+			if holder, ok := byTable[table]; ok {
+				holder.foreignKeys = appendForeignKey(holder.foreignKeys, foreignKey)
 			}
+		}
+	}
+
+	var plans []tablePlan
+	for _, migration := range append(dependencyOrder(migrations), joinTables...) {
+		statements, err := d.migrationPlan(ctx, migration)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, tablePlan{tableName: migration.tableName, statements: statements})
+	}
+	return plans, nil
+}
+
+// joinTableModel describes the join table of a ManyToMany relation, its composite primary key pairs
+// the keys of both models and its rows are deleted together with either model
+func joinTableModel(owner reflect.Type, relation *util.Relation, naming NamingStrategy) *migrationModel {
+	joinColumn := func(column string, key *util.Field) *util.Field {
+		return &util.Field{
+			Name:   key.Name,
+			Type:   key.Type,
+			Column: column,
+			Tag: util.Tag{
+				Type:       key.Tag.Type,
+				Size:       key.Tag.Size,
+				Precision:  key.Tag.Precision,
+				Scale:      key.Tag.Scale,
+				PrimaryKey: true,
+			},
+		}
+	}
+	return &migrationModel{
+		tableName: relation.JoinTable,
+		fields: []*util.Field{
+			joinColumn(relation.JoinForeignKey, relation.References),
+			joinColumn(relation.JoinReferences, relation.ForeignKey),
+		},
+		foreignKeys: []util.ForeignKey{
+			{Column: relation.JoinForeignKey, Table: util.TableNameOf(owner, naming), References: relation.References.Column, OnDelete: "CASCADE"},
+			{Column: relation.JoinReferences, Table: util.TableNameOf(relation.Type, naming), References: relation.ForeignKey.Column, OnDelete: "CASCADE"},
+		},
+	}
+}
+
+// appendForeignKey adds a constraint unless the column already has one, both sides of a relation
+// describe the same constraint so referential actions missing on the first one are taken from the second
+func appendForeignKey(foreignKeys []util.ForeignKey, foreignKey util.ForeignKey) []util.ForeignKey {
+	for i, existing := range foreignKeys {
+		if !strings.EqualFold(existing.Column, foreignKey.Column) {
+			continue
+		}
+		if existing.OnDelete == "" {
+			foreignKeys[i].OnDelete = foreignKey.OnDelete
+		}
+		if existing.OnUpdate == "" {
+			foreignKeys[i].OnUpdate = foreignKey.OnUpdate
+		}
+		return foreignKeys
+	}
+	return append(foreignKeys, foreignKey)
+}
+
+// dependencyOrder sorts migrations so that every table follows the tables it references,
+// the given order is kept otherwise and for tables referencing each other
+func dependencyOrder(migrations []*migrationModel) []*migrationModel {
+	pending := map[string]bool{}
+	for _, migration := range migrations {
+		pending[migration.tableName] = true
+	}
 
-			if strings.Contains(tag, "unique") {
-				sqlType += " UNIQUE"
+	ordered := make([]*migrationModel, 0, len(migrations))
+	remaining := migrations
+	for len(remaining) > 0 {
+		next := -1
+		for i, migration := range remaining {
+			ready := true
+			for _, foreignKey := range migration.foreignKeys {
+				if foreignKey.Table != migration.tableName && pending[foreignKey.Table] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				next = i
+				break
 			}
+		}
+		if next < 0 {
+			// a cycle cannot be ordered, its tables are created as given
+			next = 0
+		}
+		migration := remaining[next]
+		ordered = append(ordered, migration)
+		delete(pending, migration.tableName)
+		remaining = append(remaining[:next:next], remaining[next+1:]...)
+	}
+	return ordered
+}
 
-			columns = append(columns, fmt.Sprintf(`"%s" %s`, column, sqlType))
+// migrationPlan compares a model with the live schema and returns the statements bringing its table up to date.
+// Foreign keys are only declared when the table is created.
+func (d *Durazzo) migrationPlan(ctx context.Context, migration *migrationModel) ([]string, error) {
+	exists, err := d.tableExists(ctx, migration.tableName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect table %v: %w", migration.tableName, err)
+	}
+	if !exists {
+		return d.createTableStatements(migration.tableName, migration.fields, migration.foreignKeys), nil
+	}
+
+	statements, err := d.alterTableStatements(ctx, migration.tableName, migration.fields)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect table %v: %w", migration.tableName, err)
+	}
+	return statements, nil
+}
+
+// createTableStatements creates the table of a model together with its indexes
+func (d *Durazzo) createTableStatements(tableName string, fields []*util.Field, foreignKeys []util.ForeignKey) []string {
+	var primaryKeys []string
+	for _, field := range fields {
+		if field.Tag.PrimaryKey {
+			primaryKeys = append(primaryKeys, d.dialect.Quote(field.Column))
 		}
+	}
+	compositeKey := len(primaryKeys) > 1
 
-		createQuery := fmt.Sprintf(
-			`CREATE TABLE IF NOT EXISTS "%s" (%s);`,
-			tableName,
-			strings.Join(columns, ", "),
-		)
+	referencing := map[string]bool{}
+	for _, foreignKey := range foreignKeys {
+		referencing[strings.ToLower(foreignKey.Column)] = true
+	}
 
-		_, err := d.Db.Exec(createQuery)
-		if err != nil {
-			return fmt.Errorf("failed to create table for model %v: %w", tableName, err)
+	var columns []string
+	for _, field := range fields {
+		definition := columnDefinition(d.dialect, field, !compositeKey, true, referencing[strings.ToLower(field.Column)])
+		columns = append(columns, d.dialect.Quote(field.Column)+" "+definition)
+	}
+	if compositeKey {
+		columns = append(columns, fmt.Sprintf("PRIMARY KEY (%s)", strings.Join(primaryKeys, ", ")))
+	}
+	for _, foreignKey := range foreignKeys {
+		columns = append(columns, foreignKeyDefinition(d.dialect, foreignKey))
+	}
+
+	statements := []string{fmt.Sprintf(
+		`CREATE TABLE IF NOT EXISTS %s (%s);`,
+		quoteTable(d.dialect, tableName),
+		strings.Join(columns, ", "),
+	)}
+	for _, index := range modelIndexes(tableName, fields) {
+		statements = append(statements, index.createSQL(d.dialect, tableName))
+	}
+	return statements
+}
+
+// foreignKeyDefinition renders the FOREIGN KEY constraint of a CREATE TABLE
+func foreignKeyDefinition(dialect Dialect, foreignKey util.ForeignKey) string {
+	definition := fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (%s)",
+		dialect.Quote(foreignKey.Column), quoteTable(dialect, foreignKey.Table), dialect.Quote(foreignKey.References))
+	if foreignKey.OnDelete != "" {
+		definition += " ON DELETE " + foreignKey.OnDelete
+	}
+	if foreignKey.OnUpdate != "" {
+		definition += " ON UPDATE " + foreignKey.OnUpdate
+	}
+	return definition
+}
+
+// alterTableStatements adds the columns and indexes of a model missing from its existing table.
+// Unique columns are added with a unique index since not every dialect can add a UNIQUE column,
+// NOT NULL columns need a default to fill the rows the table already holds.
+func (d *Durazzo) alterTableStatements(ctx context.Context, tableName string, fields []*util.Field) ([]string, error) {
+	columns, err := d.existingColumns(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+	indexes, err := d.existingIndexes(ctx, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []string
+	var uniqueIndexes []index
+	_, table := splitTableName(tableName)
+	for _, field := range fields {
+		if columns[strings.ToLower(field.Column)] {
+			continue
+		}
+		if _, ok := addedColumnDefault(d.dialect, field); !ok && !field.IsNullable() {
+			return nil, fmt.Errorf("cannot add the NOT NULL column %s to the existing table %s without a default value, declare one with the default tag option",
+				field.Column, tableName)
+		}
+		statements = append(statements, fmt.Sprintf(`ALTER TABLE %s ADD COLUMN %s %s`,
+			quoteTable(d.dialect, tableName), d.dialect.Quote(field.Column), columnDefinition(d.dialect, field, false, false, false)))
+		if field.Tag.Unique && !field.Tag.PrimaryKey {
+			uniqueIndexes = append(uniqueIndexes, index{
+				name:    fmt.Sprintf("uq_%s_%s", table, field.Column),
+				columns: []string{field.Column},
+				unique:  true,
+			})
 		}
 	}
-	return nil
+
+	for _, index := range append(modelIndexes(tableName, fields), uniqueIndexes...) {
+		if !indexes[strings.ToLower(index.name)] {
+			statements = append(statements, index.createSQL(d.dialect, tableName))
+		}
+	}
+	return statements, nil
 }
 
-// Determine the SQL type for a field based on its Go type and struct tag
-func determineSQLType(goType reflect.Type, tag string) string {
-	switch goType.Kind() {
-	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		return "INTEGER"
-	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-		return "BIGINT"
-	case reflect.Float32, reflect.Float64:
-		return "REAL"
+// columnDefinition renders the type and the constraints of a column, a primary key is declared inline
+// unless it is part of a composite key. Columns are NOT NULL unless their Go type is nullable, a new
+// table declares UNIQUE inline while added columns leave it to an index and fill the rows the table
+// already holds with their default. A foreign key column references another table.
+func columnDefinition(dialect Dialect, field *util.Field, inlinePrimaryKey, create, foreignKey bool) string {
+	column := Column{
+		Name:       field.Column,
+		Type:       util.ValueType(field.Type),
+		Size:       field.Tag.Size,
+		Precision:  field.Tag.Precision,
+		Scale:      field.Tag.Scale,
+		PrimaryKey: field.Tag.PrimaryKey,
+		Unique:     field.Tag.Unique,
+		JSON:       field.Tag.Serializer == "json",
+		Indexed:    field.Tag.HasIndex || foreignKey,
+	}
+
+	if inlinePrimaryKey && field.Tag.PrimaryKey && field.IsAutoIncrement() {
+		return dialect.AutoIncrement(column)
+	}
+
+	definition := field.Tag.Type
+	if definition == "" {
+		definition, _ = registeredColumnType(column.Type, dialect.Name())
+	}
+	if definition == "" {
+		definition = dialect.DataType(column)
+	}
+	if inlinePrimaryKey && field.Tag.PrimaryKey {
+		definition += " PRIMARY KEY"
+	} else if !field.IsNullable() {
+		definition += " NOT NULL"
+	}
+	if field.Tag.HasDefault {
+		definition += " DEFAULT " + field.Tag.Default
+	} else if defaultValue, ok := addedColumnDefault(dialect, field); ok && !create && !field.IsNullable() {
+		definition += " DEFAULT " + defaultValue
+	}
+	if create && field.Tag.Unique && !field.Tag.PrimaryKey {
+		definition += " UNIQUE"
+	}
+	return definition
+}
+
+// addedColumnDefault returns the default filling the existing rows when a column is added, the default tag
+// or the zero value of strings, numbers and booleans stored with the dialect's own type
+func addedColumnDefault(dialect Dialect, field *util.Field) (string, bool) {
+	if field.Tag.HasDefault {
+		return field.Tag.Default, true
+	}
+	valueType := util.ValueType(field.Type)
+	if field.Tag.PrimaryKey || field.Tag.Type != "" || field.Tag.Serializer != "" {
+		return "", false
+	}
+	if _, ok := registeredColumnType(valueType, dialect.Name()); ok {
+		return "", false
+	}
+	switch valueType.Kind() {
 	case reflect.String:
-		if strings.Contains(tag, "size") {
-			return fmt.Sprintf("VARCHAR(%s)", extractSize(tag))
-		}
-		return "TEXT"
+		return "''", true
 	case reflect.Bool:
-		return "BOOLEAN"
+		return "FALSE", true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Float32, reflect.Float64:
+		return "0", true
 	default:
-		return "TEXT"
+		return "", false
+	}
+}
+
+// index is a secondary index declared with the index tag option
+type index struct {
+	name    string
+	columns []string
+	unique  bool
+}
+
+func (i index) createSQL(dialect Dialect, tableName string) string {
+	columns := make([]string, len(i.columns))
+	for j, column := range i.columns {
+		columns[j] = dialect.Quote(column)
+	}
+	statement := "CREATE INDEX"
+	if i.unique {
+		statement = "CREATE UNIQUE INDEX"
 	}
+	return fmt.Sprintf("%s %s ON %s (%s)", statement, dialect.Quote(i.name), quoteTable(dialect, tableName), strings.Join(columns, ", "))
 }
 
-func extractSize(tag string) string {
-	parts := strings.Split(tag, ":")
-	if len(parts) > 1 {
-		return parts[1]
+// modelIndexes groups the indexed fields by index name, fields sharing a name form a composite index
+func modelIndexes(tableName string, fields []*util.Field) []index {
+	var indexes []index
+	positions := map[string]int{}
+	for _, field := range fields {
+		if !field.Tag.HasIndex {
+			continue
+		}
+		name := field.Tag.Index
+		if name == "" {
+			_, table := splitTableName(tableName)
+			name = fmt.Sprintf("idx_%s_%s", table, field.Column)
+		}
+		if position, ok := positions[name]; ok {
+			indexes[position].columns = append(indexes[position].columns, field.Column)
+			continue
+		}
+		positions[name] = len(indexes)
+		indexes = append(indexes, index{name: name, columns: []string{field.Column}})
+	}
+	return indexes
+}
+
+// resolveTarget accepts either a table name or a model and returns the table with the model type, if any
+func (d *Durazzo) resolveTarget(target interface{}) (string, reflect.Type, error) {
+	if tableName, ok := target.(string); ok {
+		return tableName, nil, nil
+	}
+	modelType, tableName, _, err := util.ResolveModelInfo(target, d.naming)
+	if err != nil {
+		return "", nil, err
+	}
+	if modelType.Kind() != reflect.Struct {
+		return "", nil, fmt.Errorf("model %v must be a struct", modelType)
+	}
+	return tableName, modelType, nil
+}
+
+// resolveColumn maps a Go field name of modelType to its column, other names are treated as columns
+func (d *Durazzo) resolveColumn(modelType reflect.Type, name string) string {
+	if modelType == nil {
+		return name
+	}
+	fields, err := util.ParseFields(modelType, d.naming)
+	if err != nil {
+		return name
+	}
+	for _, field := range fields {
+		if field.Name == name {
+			return field.Column
+		}
+	}
+	return name
+}
+
+// serialize encodes a value written to column with the serializer of the matching field of modelType
+func (d *Durazzo) serialize(modelType reflect.Type, column string, value interface{}) interface{} {
+	if modelType == nil {
+		return value
+	}
+	fields, err := util.FieldsByColumn(modelType, d.naming)
+	if err != nil {
+		return value
+	}
+	if field, ok := fields[strings.ToLower(column)]; ok {
+		return field.Serialize(value)
+	}
+	return value
+}
+
+// fieldByColumn finds the field of a struct value stored in column
+func fieldByColumn(structValue reflect.Value, column string, naming NamingStrategy) (reflect.Value, bool) {
+	fields, err := util.FieldsByColumn(structValue.Type(), naming)
+	if err != nil {
+		return reflect.Value{}, false
+	}
+	field, ok := fields[strings.ToLower(column)]
+	if !ok {
+		return reflect.Value{}, false
 	}
-	return "255"
+	return structValue.FieldByIndex(field.Index), true
 }
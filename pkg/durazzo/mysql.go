@@ -2,6 +2,10 @@ package durazzo
 
 import (
 	"database/sql"
+	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/util"
+	"reflect"
+	"strings"
 
 	_ "github.com/go-sql-driver/mysql" // MySQL driver
 )
@@ -9,3 +13,105 @@ import (
 func initMySQL(dsn string) (*sql.DB, error) {
 	return sql.Open("mysql", dsn)
 }
+
+type mysqlDialect struct{}
+
+func (m *mysqlDialect) Name() string {
+	return Mysql
+}
+
+func (m *mysqlDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (m *mysqlDialect) Quote(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+// DataType maps keys without an explicit size to VARCHAR since MySQL cannot index TEXT columns,
+// times use DATETIME with microseconds which, unlike TIMESTAMP, is not limited to 2038.
+// Scanning them into time.Time needs parseTime=true in the DSN.
+func (m *mysqlDialect) DataType(column Column) string {
+	if column.JSON {
+		return "JSON"
+	}
+	if util.IsTimeType(column.Type) {
+		return "DATETIME(6)"
+	}
+	sqlType := baseDataType(column)
+	// MySQL cannot index a TEXT column without a key length
+	if sqlType == "TEXT" && column.Type.Kind() == reflect.String && (column.PrimaryKey || column.Unique || column.Indexed) {
+		return "VARCHAR(255)"
+	}
+	return sqlType
+}
+
+func (m *mysqlDialect) AutoIncrement(column Column) string {
+	return m.DataType(column) + " AUTO_INCREMENT PRIMARY KEY"
+}
+
+func (m *mysqlDialect) CaseInsensitiveLike(column, placeholder string) string {
+	return fmt.Sprintf("LOWER(%s) LIKE LOWER(%s)", column, placeholder)
+}
+
+func (m *mysqlDialect) LimitOffset(limit, offset int) string {
+	if limit <= 0 && offset > 0 {
+		// MySQL cannot OFFSET without a LIMIT, the documented workaround is the largest unsigned BIGINT
+		return fmt.Sprintf("LIMIT 18446744073709551615 OFFSET %d", offset)
+	}
+	return limitOffset(limit, offset)
+}
+
+func (m *mysqlDialect) TableExistsQuery(schema, table string) (string, []interface{}) {
+	if schema == "" {
+		return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = ?", []interface{}{table}
+	}
+	return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = ? AND table_name = ?", []interface{}{schema, table}
+}
+
+// TransactionalDDL is false since MySQL commits implicitly around every DDL statement
+func (m *mysqlDialect) TransactionalDDL() bool {
+	return false
+}
+
+// MigrationLock uses a named lock waiting without timeout
+func (m *mysqlDialect) MigrationLock(name string) (string, string, []interface{}) {
+	return "SELECT GET_LOCK(?, -1)", "SELECT RELEASE_LOCK(?)", []interface{}{name}
+}
+
+func (m *mysqlDialect) ColumnsQuery(schema, table string) (string, []interface{}) {
+	if schema == "" {
+		return "SELECT column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name = ?", []interface{}{table}
+	}
+	return "SELECT column_name FROM information_schema.columns WHERE table_schema = ? AND table_name = ?", []interface{}{schema, table}
+}
+
+func (m *mysqlDialect) IndexesQuery(schema, table string) (string, []interface{}) {
+	if schema == "" {
+		return "SELECT DISTINCT index_name FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name = ?", []interface{}{table}
+	}
+	return "SELECT DISTINCT index_name FROM information_schema.statistics WHERE table_schema = ? AND table_name = ?", []interface{}{schema, table}
+}
+
+// MaxParameters is bound by the 16 bit parameter count of prepared statements
+func (m *mysqlDialect) MaxParameters() int {
+	return 65535
+}
+
+// Returning is false, generated keys are read through LastInsertId instead
+func (m *mysqlDialect) Returning() bool {
+	return false
+}
+
+// OnConflict uses ON DUPLICATE KEY UPDATE which reacts to every unique key, skipping assigns an
+// inserted column to itself since INSERT IGNORE would also swallow unrelated errors
+func (m *mysqlDialect) OnConflict(_, update, inserted []string) string {
+	if len(update) == 0 {
+		return fmt.Sprintf("ON DUPLICATE KEY UPDATE %s = %s", inserted[0], inserted[0])
+	}
+	assignments := make([]string, 0, len(update))
+	for _, column := range update {
+		assignments = append(assignments, fmt.Sprintf("%s = VALUES(%s)", column, column))
+	}
+	return "ON DUPLICATE KEY UPDATE " + strings.Join(assignments, ", ")
+}
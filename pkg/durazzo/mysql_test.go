@@ -0,0 +1,19 @@
+package durazzo
+
+import (
+	"github.com/stretchr/testify/assert"
+	"reflect"
+	"testing"
+)
+
+func TestMysqlDialect_IndexedStrings(t *testing.T) {
+	dialect := &mysqlDialect{}
+	stringType := reflect.TypeOf("")
+
+	assert.Equal(t, "TEXT", dialect.DataType(Column{Name: "bio", Type: stringType}))
+	assert.Equal(t, "VARCHAR(255)", dialect.DataType(Column{Name: "email", Type: stringType, Unique: true}))
+	assert.Equal(t, "VARCHAR(255)", dialect.DataType(Column{Name: "nickname", Type: stringType, Indexed: true}),
+		"MySQL cannot index a TEXT column without a key length")
+	assert.Equal(t, "VARCHAR(40)", dialect.DataType(Column{Name: "code", Type: stringType, Size: 40, Indexed: true}))
+	assert.Equal(t, "BIGINT", dialect.DataType(Column{Name: "authorid", Type: reflect.TypeOf(int64(0)), Indexed: true}))
+}
@@ -0,0 +1,12 @@
+package durazzo
+
+import "github.com/EraldCaka/durazzo/pkg/util"
+
+// NamingStrategy maps Go type and field names to table and column names
+type NamingStrategy = util.NamingStrategy
+
+// Naming is the configurable NamingStrategy, its zero value lower cases type and field names
+type Naming = util.Naming
+
+// Tabler is implemented by models that choose their own table name, it takes precedence over the NamingStrategy
+type Tabler = util.Tabler
@@ -0,0 +1,68 @@
+package durazzo_test
+
+import (
+	"github.com/EraldCaka/durazzo/pkg/durazzo"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+type BlogCategory struct {
+	CategoryID  int    `durazzo:"primary_key"`
+	DisplayName string `durazzo:"size:100"`
+}
+
+type LegacyUser struct {
+	ID   int `durazzo:"primary_key"`
+	Name string
+}
+
+func (LegacyUser) TableName() string {
+	return "accounts"
+}
+
+func TestNaming_Strategy(t *testing.T) {
+	newDurazzo, err := durazzo.NewDurazzo(durazzo.Config{
+		Driver: durazzo.Sqlite,
+		DSN:    filepath.Join(t.TempDir(), "durazzo.db"),
+		NamingStrategy: durazzo.Naming{
+			Schema:       "main",
+			TablePrefix:  "app_",
+			SnakeCase:    true,
+			PluralTables: true,
+		},
+	})
+	if err != nil {
+		t.Fatalf("failed to open the sqlite database: %v", err)
+	}
+	defer newDurazzo.Close()
+
+	err = newDurazzo.AutoMigrate(&BlogCategory{}, &LegacyUser{})
+	assert.Nil(t, err)
+
+	err = newDurazzo.Insert(&BlogCategory{CategoryID: 1, DisplayName: "Go"}).Run()
+	assert.Nil(t, err)
+	err = newDurazzo.Insert(&LegacyUser{ID: 1, Name: "kris"}).Run()
+	assert.Nil(t, err)
+
+	var count int
+	err = newDurazzo.Raw(`SELECT COUNT(display_name) FROM main.app_blog_categories`).Model(&count).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, count)
+
+	var categories []BlogCategory
+	err = newDurazzo.Select(&categories).Where("display_name", "Go").Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []BlogCategory{{CategoryID: 1, DisplayName: "Go"}}, categories)
+
+	err = newDurazzo.Update(&BlogCategory{}).Set("DisplayName", "Golang").Where("CategoryID", 1).Run()
+	assert.Nil(t, err)
+
+	var legacy LegacyUser
+	err = newDurazzo.Select(&legacy).Where("name", "kris").Run()
+	assert.Nil(t, err)
+	assert.Equal(t, LegacyUser{ID: 1, Name: "kris"}, legacy)
+
+	err = newDurazzo.Delete(&BlogCategory{}).Where("display_name", "Golang").Run()
+	assert.Nil(t, err)
+}
@@ -0,0 +1,186 @@
+package durazzo
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/util"
+	"reflect"
+	"strings"
+)
+
+// Paginate continues a keyset pagination after the row whose OrderBy keys hold afterValues,
+// one value is expected per OrderBy key and in the same order
+func (st *SelectType) Paginate(afterValues ...interface{}) *SelectType {
+	st.after, st.afterToken = afterValues, nil
+	return st
+}
+
+// AfterToken continues a keyset pagination from a token returned by NextPageToken, its values are
+// decoded into the types of the fields of the OrderBy keys when the query runs
+func (st *SelectType) AfterToken(token string) *SelectType {
+	if token == "" {
+		return st
+	}
+	values, err := decodePageToken(token)
+	if err != nil {
+		st.err = err
+		return st
+	}
+	st.after, st.afterToken = nil, values
+	return st
+}
+
+// NextPageToken returns an opaque token pointing after the last row loaded by Run.
+// It is empty when the query had no OrderBy keys or no Limit, or when there is no further page.
+func (st *SelectType) NextPageToken() string {
+	return st.nextPageToken
+}
+
+// keysetCondition selects the rows sorted after a given set of key values
+type keysetCondition struct {
+	orders []Order
+	values []interface{}
+}
+
+func (c *keysetCondition) build(dialect Dialect, args *[]interface{}) (string, error) {
+	if len(c.orders) == 0 {
+		return "", errors.New("Paginate requires at least one OrderBy key")
+	}
+	if len(c.orders) != len(c.values) {
+		return "", fmt.Errorf("Paginate expects %d values, one per OrderBy key, got %d", len(c.orders), len(c.values))
+	}
+
+	sameDirection := true
+	for _, order := range c.orders {
+		sameDirection = sameDirection && order.Direction == c.orders[0].Direction
+	}
+
+	if sameDirection {
+		operator := ">"
+		if c.orders[0].Direction == Desc {
+			operator = "<"
+		}
+		if len(c.orders) == 1 {
+			return fmt.Sprintf("%s %s %s", quoteColumn(dialect, c.orders[0].Column), operator, bind(dialect, args, c.values[0])), nil
+		}
+
+		columns := make([]string, len(c.orders))
+		placeholders := make([]string, len(c.orders))
+		for i, order := range c.orders {
+			columns[i] = quoteColumn(dialect, order.Column)
+			placeholders[i] = bind(dialect, args, c.values[i])
+		}
+		return fmt.Sprintf("(%s) %s (%s)", strings.Join(columns, ", "), operator, strings.Join(placeholders, ", ")), nil
+	}
+
+	// row values cannot compare keys sorted in different directions, expand to
+	// (a > x) OR (a = x AND b < y) OR ...
+	branches := make([]Condition, len(c.orders))
+	for i, order := range c.orders {
+		branch := make([]Condition, 0, i+1)
+		for j := 0; j < i; j++ {
+			branch = append(branch, Eq(c.orders[j].Column, c.values[j]))
+		}
+		if order.Direction == Desc {
+			branch = append(branch, Lt(order.Column, c.values[i]))
+		} else {
+			branch = append(branch, Gt(order.Column, c.values[i]))
+		}
+		branches[i] = And(branch...)
+	}
+	return Or(branches...).build(dialect, args)
+}
+
+// buildNextPageToken encodes the OrderBy keys of the last loaded row
+func (st *SelectType) buildNextPageToken() (string, error) {
+	target := reflect.ValueOf(st.model)
+	if target.Kind() != reflect.Ptr || target.Elem().Kind() != reflect.Slice {
+		return "", nil
+	}
+
+	// without a limit every row was loaded, a short page is the last one
+	rows := target.Elem()
+	if st.limit <= 0 || rows.Len() < st.limit {
+		return "", nil
+	}
+
+	last := reflect.Indirect(rows.Index(rows.Len() - 1))
+	values := make([]interface{}, len(st.orders))
+	for i, order := range st.orders {
+		column := order.Column[strings.LastIndex(order.Column, ".")+1:]
+		field, ok := fieldByColumn(last, column, st.naming)
+		if !ok {
+			return "", nil
+		}
+		values[i] = field.Interface()
+	}
+	return encodePageToken(values)
+}
+
+func encodePageToken(values []interface{}) (string, error) {
+	encoded, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode page token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(encoded), nil
+}
+
+func decodePageToken(token string) ([]json.RawMessage, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, fmt.Errorf("invalid page token: %w", err)
+	}
+	var values []json.RawMessage
+	if err := json.Unmarshal(decoded, &values); err != nil {
+		return nil, fmt.Errorf("invalid page token: %w", err)
+	}
+	return values, nil
+}
+
+// pageTokenValues decodes the values of a page token into the types of the model fields of the OrderBy keys,
+// e.g. back into a time.Time rather than its text. Keys without a field keep the JSON type of their value.
+func (st *SelectType) pageTokenValues() ([]interface{}, error) {
+	var fields map[string]*util.Field
+	if st.modelType.Kind() == reflect.Struct {
+		var err error
+		if fields, err = util.FieldsByColumn(st.modelType, st.naming); err != nil {
+			return nil, err
+		}
+	}
+
+	values := make([]interface{}, len(st.afterToken))
+	for i, raw := range st.afterToken {
+		var field *util.Field
+		if i < len(st.orders) {
+			column := st.orders[i].Column
+			field = fields[strings.ToLower(column[strings.LastIndex(column, ".")+1:])]
+		}
+		if field != nil {
+			value := reflect.New(field.Type)
+			if err := json.Unmarshal(raw, value.Interface()); err != nil {
+				return nil, fmt.Errorf("invalid page token: %w", err)
+			}
+			values[i] = value.Elem().Interface()
+			continue
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&values[i]); err != nil {
+			return nil, fmt.Errorf("invalid page token: %w", err)
+		}
+		number, ok := values[i].(json.Number)
+		if !ok {
+			continue
+		}
+		if integer, err := number.Int64(); err == nil {
+			values[i] = integer
+		} else if float, err := number.Float64(); err == nil {
+			values[i] = float
+		}
+	}
+	return values, nil
+}
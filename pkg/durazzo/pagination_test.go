@@ -0,0 +1,117 @@
+package durazzo_test
+
+import (
+	"database/sql"
+	"github.com/EraldCaka/durazzo/pkg/durazzo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestSelect_OrderByOffset(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+	insertConditionUsers(t, newDurazzo)
+
+	var users []User
+	err := newDurazzo.Select(&users).OrderBy("id", durazzo.Desc).Limit(2).Offset(1).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(users))
+	assert.Equal(t, "jessie", users[0].Name)
+	assert.Equal(t, "erald", users[1].Name)
+
+	var skipped []User
+	err = newDurazzo.Select(&skipped).OrderBy("email", durazzo.Asc).OrderBy("id", durazzo.Asc).Offset(3).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(skipped))
+	assert.Equal(t, "Sara", skipped[0].Name)
+
+	err = newDurazzo.Select(&skipped).OrderBy("id", "sideways").Run()
+	assert.NotNil(t, err)
+}
+
+func TestSelect_Paginate(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+	insertConditionUsers(t, newDurazzo)
+
+	var firstPage []User
+	first := newDurazzo.Select(&firstPage).OrderBy("id", durazzo.Asc).Limit(3)
+	err := first.Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(firstPage))
+	assert.NotEqual(t, "", first.NextPageToken())
+
+	var secondPage []User
+	second := newDurazzo.Select(&secondPage).OrderBy("id", durazzo.Asc).Limit(3).AfterToken(first.NextPageToken())
+	err = second.Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(secondPage))
+	assert.Equal(t, "Sara", secondPage[0].Name)
+	assert.Equal(t, "", second.NextPageToken())
+
+	var everyone []User
+	all := newDurazzo.Select(&everyone).OrderBy("id", durazzo.Asc)
+	err = all.Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 4, len(everyone))
+	assert.Equal(t, "", all.NextPageToken(), "without a limit every row is on the first page")
+
+	var descending []User
+	err = newDurazzo.Select(&descending).OrderBy("name", durazzo.Desc).OrderBy("id", durazzo.Desc).Paginate("kris", 1).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(descending))
+	assert.Equal(t, "jessie", descending[0].Name)
+
+	var mixed []User
+	err = newDurazzo.Select(&mixed).OrderBy("email", durazzo.Asc).OrderBy("id", durazzo.Desc).Paginate("jessie@gmail.com", 3).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(mixed))
+	assert.Equal(t, "kris", mixed[0].Name)
+	assert.Equal(t, "Sara", mixed[1].Name)
+
+	err = newDurazzo.Select(&mixed).OrderBy("id", durazzo.Asc).Paginate(1, 2).Run()
+	assert.NotNil(t, err)
+}
+
+type Event struct {
+	ID       int `durazzo:"primary_key"`
+	Priority sql.NullInt64
+	At       time.Time
+}
+
+func TestSelect_Paginate_TypedKeys(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+	err := newDurazzo.AutoMigrate(&Event{})
+	assert.Nil(t, err)
+
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	var events []Event
+	for i := 0; i < 5; i++ {
+		events = append(events, Event{Priority: sql.NullInt64{Int64: int64(i % 2), Valid: true}, At: start.Add(time.Duration(i) * time.Hour)})
+	}
+	err = newDurazzo.Insert(events).Run()
+	assert.Nil(t, err)
+
+	var ids []int
+	token := ""
+	for {
+		var page []Event
+		query := newDurazzo.Select(&page).OrderBy("at", durazzo.Asc).Limit(2).AfterToken(token)
+		err = query.Run()
+		assert.Nil(t, err)
+		for _, event := range page {
+			ids = append(ids, event.ID)
+		}
+		if token = query.NextPageToken(); token == "" {
+			break
+		}
+	}
+	assert.Equal(t, []int{1, 2, 3, 4, 5}, ids, "the time key is bound as a time")
+
+	var first, second []Event
+	query := newDurazzo.Select(&first).OrderBy("priority", durazzo.Desc).OrderBy("at", durazzo.Asc).Limit(2)
+	err = query.Run()
+	assert.Nil(t, err)
+	err = newDurazzo.Select(&second).AfterToken(query.NextPageToken()).OrderBy("priority", durazzo.Desc).OrderBy("at", durazzo.Asc).Limit(2).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []Event{events[3], events[0]}, append(first[1:], second[:1]...), "nullable keys are bound through their Valuer")
+}
@@ -1,11 +1,136 @@
 package durazzo
 
 import (
+	"context"
 	"database/sql"
+	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/util"
+	"hash/fnv"
+	"reflect"
+	"strings"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 func initPostgres(dsn string) (*sql.DB, error) {
 	return sql.Open("postgres", dsn)
 }
+
+type postgresDialect struct{}
+
+func (p *postgresDialect) Name() string {
+	return Postgres
+}
+
+func (p *postgresDialect) Placeholder(n int) string {
+	return fmt.Sprintf("$%d", n)
+}
+
+func (p *postgresDialect) Quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// DataType stores JSON as JSONB and times as TIMESTAMPTZ so that they keep their instant whatever the session time zone
+func (p *postgresDialect) DataType(column Column) string {
+	if column.JSON {
+		return "JSONB"
+	}
+	if util.IsTimeType(column.Type) {
+		return "TIMESTAMPTZ"
+	}
+	return baseDataType(column)
+}
+
+func (p *postgresDialect) AutoIncrement(column Column) string {
+	if column.Type.Kind() == reflect.Int64 || column.Type.Kind() == reflect.Uint64 {
+		return "BIGSERIAL PRIMARY KEY"
+	}
+	return "SERIAL PRIMARY KEY"
+}
+
+func (p *postgresDialect) CaseInsensitiveLike(column, placeholder string) string {
+	return fmt.Sprintf("%s ILIKE %s", column, placeholder)
+}
+
+func (p *postgresDialect) LimitOffset(limit, offset int) string {
+	return limitOffset(limit, offset)
+}
+
+func (p *postgresDialect) TableExistsQuery(schema, table string) (string, []interface{}) {
+	if schema == "" {
+		return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = current_schema() AND table_name = $1", []interface{}{table}
+	}
+	return "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = $1 AND table_name = $2", []interface{}{schema, table}
+}
+
+func (p *postgresDialect) TransactionalDDL() bool {
+	return true
+}
+
+// MigrationLock uses an advisory lock keyed by the hash of name
+func (p *postgresDialect) MigrationLock(name string) (string, string, []interface{}) {
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(name))
+	key := int64(hash.Sum64())
+	return "SELECT pg_advisory_lock($1)", "SELECT pg_advisory_unlock($1)", []interface{}{key}
+}
+
+func (p *postgresDialect) ColumnsQuery(schema, table string) (string, []interface{}) {
+	if schema == "" {
+		return "SELECT column_name FROM information_schema.columns WHERE table_schema = current_schema() AND table_name = $1", []interface{}{table}
+	}
+	return "SELECT column_name FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2", []interface{}{schema, table}
+}
+
+// IndexesQuery reads pg_indexes since information_schema does not describe indexes
+func (p *postgresDialect) IndexesQuery(schema, table string) (string, []interface{}) {
+	if schema == "" {
+		return "SELECT indexname FROM pg_indexes WHERE schemaname = current_schema() AND tablename = $1", []interface{}{table}
+	}
+	return "SELECT indexname FROM pg_indexes WHERE schemaname = $1 AND tablename = $2", []interface{}{schema, table}
+}
+
+// MaxParameters is bound by the 16 bit parameter count of the wire protocol
+func (p *postgresDialect) MaxParameters() int {
+	return 65535
+}
+
+// copyIn loads rows into table with COPY FROM STDIN, the driver requires it to run inside tx
+func copyIn(ctx context.Context, tx *sql.Tx, table string, fields []*util.Field, rows []reflect.Value) error {
+	columns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		columns = append(columns, field.Column)
+	}
+	copyQuery := pq.CopyIn(table, columns...)
+	if schema, name := splitTableName(table); schema != "" {
+		copyQuery = pq.CopyInSchema(schema, name, columns...)
+	}
+	stmt, err := tx.PrepareContext(ctx, copyQuery)
+	if err != nil {
+		return fmt.Errorf("failed to prepare COPY: %w", err)
+	}
+	defer func(stmt *sql.Stmt) {
+		_ = stmt.Close()
+	}(stmt)
+
+	values := make([]interface{}, len(fields))
+	for _, row := range rows {
+		for i, field := range fields {
+			values[i] = field.ColumnValue(row)
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return err
+		}
+	}
+	// the final call without arguments flushes the buffered rows
+	_, err = stmt.ExecContext(ctx)
+	return err
+}
+
+func (p *postgresDialect) Returning() bool {
+	return true
+}
+
+func (p *postgresDialect) OnConflict(conflict, update, _ []string) string {
+	return onConflict(conflict, update)
+}
@@ -0,0 +1,280 @@
+package durazzo
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/util"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Preload loads an association of the selected models after the main query, with one
+// WHERE key IN (...) query per association instead of one per model. Nested associations
+// are separated by dots, e.g. Preload("Posts.Comments") loads the posts and their comments.
+func (st *SelectType) Preload(association string) *SelectType {
+	st.preloads = append(st.preloads, association)
+	return st
+}
+
+// preloadTree holds the associations to load below a model, keyed by field name
+type preloadTree map[string]preloadTree
+
+func (pt preloadTree) add(path []string) {
+	child, ok := pt[path[0]]
+	if !ok {
+		child = preloadTree{}
+		pt[path[0]] = child
+	}
+	if len(path) > 1 {
+		child.add(path[1:])
+	}
+}
+
+// preload loads the associations of the models the main query scanned
+func (st *SelectType) preload(ctx context.Context) error {
+	if st.modelType.Kind() != reflect.Struct {
+		return fmt.Errorf("cannot preload associations of %s", st.modelType)
+	}
+	tree := preloadTree{}
+	for _, association := range st.preloads {
+		tree.add(strings.Split(association, "."))
+	}
+	return st.Durazzo.preloadLevel(ctx, st.modelType, structValues(reflect.ValueOf(st.model)), tree)
+}
+
+// structValues returns the addressable structs held by a pointer to a struct or to a slice of structs
+func structValues(value reflect.Value) []reflect.Value {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+	switch value.Kind() {
+	case reflect.Struct:
+		return []reflect.Value{value}
+	case reflect.Slice:
+		var values []reflect.Value
+		for i := 0; i < value.Len(); i++ {
+			values = append(values, structValues(value.Index(i).Addr())...)
+		}
+		return values
+	default:
+		return nil
+	}
+}
+
+// preloadLevel loads the associations in tree for parents of structType, then their nested associations
+func (d *Durazzo) preloadLevel(ctx context.Context, structType reflect.Type, parents []reflect.Value, tree preloadTree) error {
+	if len(parents) == 0 {
+		return nil
+	}
+	relations, err := util.ParseRelations(structType, d.naming)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(tree))
+	for name := range tree {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var relation *util.Relation
+		for _, candidate := range relations {
+			if candidate.Name == name {
+				relation = candidate
+			}
+		}
+		if relation == nil {
+			return fmt.Errorf("%s has no association %s", structType.Name(), name)
+		}
+		if err := d.preloadRelation(ctx, relation, parents, tree[name]); err != nil {
+			return fmt.Errorf("failed to preload %s.%s: %w", structType.Name(), name, err)
+		}
+	}
+	return nil
+}
+
+// preloadRelation queries the associated models of parents, loads their own associations and stores them in parents
+func (d *Durazzo) preloadRelation(ctx context.Context, relation *util.Relation, parents []reflect.Value, children preloadTree) error {
+	// the parent side of the key and the column it is matched with on the associated table
+	parentKey, relatedKey := relation.References, relation.ForeignKey
+	if relation.Kind == util.BelongsTo {
+		parentKey, relatedKey = relation.ForeignKey, relation.References
+	}
+
+	var keys []interface{}
+	seen := map[string]bool{}
+	for _, parent := range parents {
+		key := parent.FieldByIndex(parentKey.Index)
+		normalized, ok := preloadKey(key)
+		if !ok || seen[normalized] {
+			continue
+		}
+		seen[normalized] = true
+		keys = append(keys, key.Interface())
+	}
+
+	// links maps the key of every parent to the keys of its associated models
+	var links map[string][]interface{}
+	if relation.Kind == util.ManyToMany {
+		var err error
+		if links, keys, err = d.joinTableLinks(ctx, relation, keys); err != nil {
+			return err
+		}
+	}
+
+	related, err := d.loadByKeys(ctx, relation.Type, relatedKey.Column, keys)
+	if err != nil {
+		return err
+	}
+	if len(children) > 0 {
+		// nested associations are loaded before the models are copied into their parents
+		if err := d.preloadLevel(ctx, relation.Type, related, children); err != nil {
+			return err
+		}
+	}
+
+	byKey := map[string][]reflect.Value{}
+	for _, model := range related {
+		if key, ok := preloadKey(model.FieldByIndex(relatedKey.Index)); ok {
+			byKey[key] = append(byKey[key], model)
+		}
+	}
+
+	for _, parent := range parents {
+		key, ok := preloadKey(parent.FieldByIndex(parentKey.Index))
+		if !ok {
+			continue
+		}
+		if links == nil {
+			setAssociation(parent.FieldByIndex(relation.Index), byKey[key])
+			continue
+		}
+		var models []reflect.Value
+		for _, link := range links[key] {
+			linked, _ := preloadKey(reflect.ValueOf(link))
+			models = append(models, byKey[linked]...)
+		}
+		setAssociation(parent.FieldByIndex(relation.Index), models)
+	}
+	return nil
+}
+
+// loadByKeys selects the models of structType whose column holds one of keys, chunked to the parameter limit
+func (d *Durazzo) loadByKeys(ctx context.Context, structType reflect.Type, column string, keys []interface{}) ([]reflect.Value, error) {
+	loaded := reflect.New(reflect.SliceOf(structType))
+	for start := 0; start < len(keys); start += d.dialect.MaxParameters() {
+		chunk := keys[start:min(start+d.dialect.MaxParameters(), len(keys))]
+		batch := reflect.New(reflect.SliceOf(structType))
+		if err := d.Select(batch.Interface()).Filter(In(column, chunk...)).RunContext(ctx); err != nil {
+			return nil, err
+		}
+		loaded.Elem().Set(reflect.AppendSlice(loaded.Elem(), batch.Elem()))
+	}
+	return structValues(loaded), nil
+}
+
+// joinTableLinks reads the join table rows of the parent keys, it returns the associated keys per parent
+// key in the order the rows were read and the distinct associated keys to load
+func (d *Durazzo) joinTableLinks(ctx context.Context, relation *util.Relation, keys []interface{}) (map[string][]interface{}, []interface{}, error) {
+	links := map[string][]interface{}{}
+	var relatedKeys []interface{}
+	seen := map[string]bool{}
+	for start := 0; start < len(keys); start += d.dialect.MaxParameters() {
+		chunk := keys[start:min(start+d.dialect.MaxParameters(), len(keys))]
+		var args []interface{}
+		where, err := buildConditions(d.dialect, []Condition{In(relation.JoinForeignKey, chunk...)}, &args)
+		if err != nil {
+			return nil, nil, err
+		}
+		query := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s",
+			d.dialect.Quote(relation.JoinForeignKey), d.dialect.Quote(relation.JoinReferences), quoteTable(d.dialect, relation.JoinTable), where)
+
+		rows, err := d.conn.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, nil, err
+		}
+		for rows.Next() {
+			var parentKey, relatedKey interface{}
+			if err := rows.Scan(&parentKey, &relatedKey); err != nil {
+				_ = rows.Close()
+				return nil, nil, err
+			}
+			parent, ok := preloadKey(reflect.ValueOf(parentKey))
+			related, relatedOk := preloadKey(reflect.ValueOf(relatedKey))
+			if !ok || !relatedOk {
+				continue
+			}
+			links[parent] = append(links[parent], relatedKey)
+			if !seen[related] {
+				seen[related] = true
+				relatedKeys = append(relatedKeys, relatedKey)
+			}
+		}
+		err = rows.Err()
+		_ = rows.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return links, relatedKeys, nil
+}
+
+// setAssociation stores models in an association field of type T, *T, []T or []*T
+func setAssociation(field reflect.Value, models []reflect.Value) {
+	fieldType := field.Type()
+	if fieldType.Kind() == reflect.Slice {
+		slice := reflect.MakeSlice(fieldType, 0, len(models))
+		for _, model := range models {
+			if fieldType.Elem().Kind() == reflect.Ptr {
+				slice = reflect.Append(slice, model.Addr())
+			} else {
+				slice = reflect.Append(slice, model)
+			}
+		}
+		field.Set(slice)
+		return
+	}
+
+	if len(models) == 0 {
+		field.Set(reflect.Zero(fieldType))
+		return
+	}
+	if fieldType.Kind() == reflect.Ptr {
+		field.Set(models[0].Addr())
+	} else {
+		field.Set(models[0])
+	}
+}
+
+// preloadKey renders a key value comparably across Go types, e.g. an int key matching an *int64 foreign key.
+// NULL and zero keys match nothing.
+func preloadKey(value reflect.Value) (string, bool) {
+	for value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return "", false
+		}
+		value = value.Elem()
+	}
+	if !value.IsValid() || value.IsZero() {
+		return "", false
+	}
+	key := value.Interface()
+	if valuer, ok := key.(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil || v == nil {
+			return "", false
+		}
+		key = v
+	}
+	if text, ok := key.([]byte); ok {
+		// drivers scan text columns into interface values as bytes
+		key = string(text)
+	}
+	return fmt.Sprint(key), true
+}
@@ -1,6 +1,7 @@
 package durazzo
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"github.com/EraldCaka/durazzo/pkg/util"
@@ -14,7 +15,7 @@ import (
 func (d *Durazzo) Raw(query string, args ...interface{}) *RawQuery {
 	return &RawQuery{
 		Durazzo: d,
-		query:   autoQuoteIdentifiers(query),
+		query:   autoQuoteIdentifiers(query, d.dialect),
 		args:    args,
 	}
 }
@@ -34,7 +35,12 @@ func (rq *RawQuery) Model(model interface{}) *RawQuery {
 
 // Run executes the raw query and maps the results
 func (rq *RawQuery) Run() error {
-	rows, err := rq.Durazzo.Db.Query(rq.query, rq.args...)
+	return rq.RunContext(context.Background())
+}
+
+// RunContext executes the raw query using ctx and maps the results
+func (rq *RawQuery) RunContext(ctx context.Context) error {
+	rows, err := rq.Durazzo.conn.QueryContext(ctx, rq.query, rq.args...)
 	if err != nil {
 		return fmt.Errorf("error executing raw query: %w", err)
 	}
@@ -46,16 +52,16 @@ func (rq *RawQuery) Run() error {
 	}(rows)
 
 	if rq.model != nil {
-		modelType, _, isPointer, err := util.ResolveModelInfo(rq.model)
+		modelType, _, isPointer, err := util.ResolveModelInfo(rq.model, rq.naming)
 		if err != nil {
 			return fmt.Errorf("error resolving model info: %w", err)
 		}
 
 		if reflect.TypeOf(rq.model).Elem().Kind() == reflect.Slice {
-			err = util.MapRowsToSliceModel(rows, rq.model, modelType)
+			err = util.MapRowsToSliceModel(rows, rq.model, modelType, rq.naming)
 			return err
 		}
-		err = util.MapRowsToModel(rows, rq.model, modelType, isPointer)
+		err = util.MapRowsToModel(rows, rq.model, modelType, isPointer, rq.naming)
 		return err
 	}
 
@@ -63,7 +69,7 @@ func (rq *RawQuery) Run() error {
 }
 
 // autoQuoteIdentifiers adds quotes to table and column names in the query
-func autoQuoteIdentifiers(query string) string {
+func autoQuoteIdentifiers(query string, dialect Dialect) string {
 	identifierRegex := regexp.MustCompile(`\b[a-zA-Z_][a-zA-Z0-9_]*\b`)
 
 	reservedKeywords := map[string]bool{
@@ -90,6 +96,6 @@ func autoQuoteIdentifiers(query string) string {
 		if _, isReserved := reservedKeywords[strings.ToLower(match)]; isReserved {
 			return match
 		}
-		return fmt.Sprintf(`"%s"`, match)
+		return dialect.Quote(match)
 	})
 }
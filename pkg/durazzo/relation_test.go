@@ -0,0 +1,205 @@
+package durazzo_test
+
+import (
+	"context"
+	"github.com/EraldCaka/durazzo/pkg/durazzo"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"testing"
+)
+
+type Author struct {
+	ID    int `durazzo:"primary_key"`
+	Name  string
+	Books []Book
+}
+
+type Book struct {
+	ID       int `durazzo:"primary_key"`
+	Title    string
+	AuthorID int
+	Author   *Author `durazzo:"on_delete:cascade"`
+}
+
+type Review struct {
+	ID     int `durazzo:"primary_key"`
+	BookID int `durazzo:"foreign_key references:book(id) on_delete:cascade"`
+	Stars  int
+}
+
+func TestRelation_AutoMigrateForeignKeys(t *testing.T) {
+	newDurazzo, err := durazzo.NewDurazzo(durazzo.Config{
+		Driver: durazzo.Sqlite,
+		DSN:    filepath.Join(t.TempDir(), "durazzo.db") + "?_foreign_keys=on",
+	})
+	assert.Nil(t, err)
+	t.Cleanup(func() {
+		assert.Nil(t, newDurazzo.Close())
+	})
+
+	plan, err := newDurazzo.AutoMigrateDryRun(context.Background(), &Review{}, &Author{}, &Book{})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{
+		`CREATE TABLE IF NOT EXISTS "author" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "name" TEXT NOT NULL);`,
+		`CREATE TABLE IF NOT EXISTS "book" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "title" TEXT NOT NULL, "authorid" INTEGER NOT NULL, ` +
+			`FOREIGN KEY ("authorid") REFERENCES "author" ("id") ON DELETE CASCADE);`,
+		`CREATE TABLE IF NOT EXISTS "review" ("id" INTEGER PRIMARY KEY AUTOINCREMENT, "bookid" INTEGER NOT NULL, "stars" INTEGER NOT NULL, ` +
+			`FOREIGN KEY ("bookid") REFERENCES "book" ("id") ON DELETE CASCADE);`,
+	}, plan)
+
+	err = newDurazzo.AutoMigrate(&Review{}, &Author{}, &Book{})
+	assert.Nil(t, err)
+
+	author := Author{Name: "kris", Books: []Book{{Title: "ignored"}}}
+	err = newDurazzo.Insert(&author).Run()
+	assert.Nil(t, err)
+	book := Book{Title: "durazzo", AuthorID: author.ID}
+	err = newDurazzo.Insert(&book).Run()
+	assert.Nil(t, err)
+	err = newDurazzo.Insert(&Review{BookID: book.ID, Stars: 5}).Run()
+	assert.Nil(t, err)
+	err = newDurazzo.Insert(&Book{Title: "orphan", AuthorID: 99}).Run()
+	assert.NotNil(t, err, "the author does not exist")
+
+	err = newDurazzo.DeleteModel(&author).Run()
+	assert.Nil(t, err)
+	var reviews []Review
+	err = newDurazzo.Select(&reviews).Run()
+	assert.Nil(t, err)
+	assert.Empty(t, reviews, "deleting the author cascades to books and reviews")
+}
+
+func TestRelation_InvalidForeignKey(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	type Orphan struct {
+		ID     int `durazzo:"primary_key"`
+		Author *Author
+	}
+	err := newDurazzo.AutoMigrate(&Orphan{})
+	assert.NotNil(t, err)
+}
+
+type Reader struct {
+	ID      int `durazzo:"primary_key"`
+	BookID  int
+	Name    string
+	Book    Book
+	Reviews []*Review `durazzo:"foreign_key:BookID references:BookID"`
+}
+
+func TestRelation_Preload(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	err := newDurazzo.AutoMigrate(&Author{}, &Book{}, &Review{}, &Reader{})
+	assert.Nil(t, err)
+	authors := []Author{{Name: "kris"}, {Name: "erald"}, {Name: "jessie"}}
+	err = newDurazzo.Insert(authors).Run()
+	assert.Nil(t, err)
+	books := []Book{
+		{Title: "first", AuthorID: authors[0].ID},
+		{Title: "second", AuthorID: authors[0].ID},
+		{Title: "third", AuthorID: authors[1].ID},
+	}
+	err = newDurazzo.Insert(books).Run()
+	assert.Nil(t, err)
+	reviews := []Review{{BookID: books[0].ID, Stars: 5}, {BookID: books[0].ID, Stars: 3}, {BookID: books[2].ID, Stars: 4}}
+	err = newDurazzo.Insert(reviews).Run()
+	assert.Nil(t, err)
+
+	var loaded []Author
+	err = newDurazzo.Select(&loaded).Preload("Books").OrderBy("id", durazzo.Asc).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(loaded))
+	assert.Equal(t, []Book{books[0], books[1]}, loaded[0].Books)
+	assert.Equal(t, []Book{books[2]}, loaded[1].Books)
+	assert.Empty(t, loaded[2].Books)
+
+	var book Book
+	err = newDurazzo.Select(&book).Where("id", books[2].ID).Preload("Author.Books").Run()
+	assert.Nil(t, err)
+	assert.NotNil(t, book.Author)
+	assert.Equal(t, "erald", book.Author.Name)
+	assert.Equal(t, []Book{books[2]}, book.Author.Books)
+
+	readers := []Reader{{BookID: books[0].ID, Name: "sara"}, {BookID: books[2].ID, Name: "edgar"}}
+	err = newDurazzo.Insert(readers).Run()
+	assert.Nil(t, err)
+	var loadedReaders []*Reader
+	err = newDurazzo.Select(&loadedReaders).Preload("Book.Author").Preload("Reviews").OrderBy("id", durazzo.Asc).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, "first", loadedReaders[0].Book.Title)
+	assert.Equal(t, "kris", loadedReaders[0].Book.Author.Name)
+	assert.Equal(t, 2, len(loadedReaders[0].Reviews))
+	assert.Equal(t, 4, loadedReaders[1].Reviews[0].Stars)
+
+	err = newDurazzo.Select(&loaded).Preload("Readers").Run()
+	assert.NotNil(t, err)
+}
+
+type Member struct {
+	ID    int `durazzo:"primary_key"`
+	Name  string
+	Roles []Role `durazzo:"many2many:member_roles"`
+}
+
+type Role struct {
+	ID   int `durazzo:"primary_key"`
+	Name string
+}
+
+func TestRelation_ManyToMany(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+	ctx := context.Background()
+
+	plan, err := newDurazzo.AutoMigrateDryRun(ctx, &Member{}, &Role{})
+	assert.Nil(t, err)
+	assert.Equal(t, `CREATE TABLE IF NOT EXISTS "member_roles" ("memberid" INTEGER NOT NULL, "roleid" INTEGER NOT NULL, `+
+		`PRIMARY KEY ("memberid", "roleid"), FOREIGN KEY ("memberid") REFERENCES "member" ("id") ON DELETE CASCADE, `+
+		`FOREIGN KEY ("roleid") REFERENCES "role" ("id") ON DELETE CASCADE);`, plan[len(plan)-1])
+
+	err = newDurazzo.AutoMigrate(&Member{}, &Role{})
+	assert.Nil(t, err)
+	members := []Member{{Name: "kris"}, {Name: "erald"}}
+	err = newDurazzo.Insert(members).Run()
+	assert.Nil(t, err)
+	admin := Role{Name: "admin"}
+	err = newDurazzo.Insert(&admin).Run()
+	assert.Nil(t, err)
+
+	editor := Role{Name: "editor"}
+	err = newDurazzo.Association(&members[0], "Roles").Append(ctx, &admin, &editor)
+	assert.Nil(t, err)
+	assert.NotZero(t, editor.ID, "roles without a key are inserted")
+	assert.Equal(t, []Role{admin, editor}, members[0].Roles)
+	err = newDurazzo.Association(&members[0], "Roles").Append(ctx, &admin, admin)
+	assert.Nil(t, err, "existing links are kept")
+	assert.Equal(t, []Role{admin, editor}, members[0].Roles, "linked roles are not appended twice")
+	count, err := newDurazzo.Association(&members[0], "Roles").Count(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count)
+
+	err = newDurazzo.Association(&members[1], "Roles").Replace(ctx, &editor)
+	assert.Nil(t, err)
+	var loaded []Member
+	err = newDurazzo.Select(&loaded).Preload("Roles").OrderBy("id", durazzo.Asc).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(loaded[0].Roles))
+	assert.Equal(t, []Role{editor}, loaded[1].Roles)
+
+	err = newDurazzo.Association(&members[0], "Roles").Remove(ctx, &admin)
+	assert.Nil(t, err)
+	assert.Equal(t, []Role{editor}, members[0].Roles)
+	err = newDurazzo.Association(&members[1], "Roles").Clear(ctx)
+	assert.Nil(t, err)
+	count, err = newDurazzo.Association(&members[1], "Roles").Count(ctx)
+	assert.Nil(t, err)
+	assert.Zero(t, count)
+	var roles []Role
+	err = newDurazzo.Select(&roles).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(roles), "unlinking keeps the roles")
+
+	err = newDurazzo.Association(&members[0], "Name").Clear(ctx)
+	assert.NotNil(t, err)
+}
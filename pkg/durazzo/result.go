@@ -0,0 +1,109 @@
+package durazzo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/util"
+	"reflect"
+)
+
+// ErrNoRowsAffected is returned when a write guarded by ExpectRows changed another number of rows
+var ErrNoRowsAffected = errors.New("unexpected number of rows affected")
+
+// Result describes the outcome of an UPDATE or DELETE
+type Result struct {
+	// RowsAffected is the number of rows changed by the statement
+	RowsAffected int64
+}
+
+// write holds the options shared by UPDATE and DELETE
+type write struct {
+	expectRows int64
+	expect     bool
+	returning  interface{}
+}
+
+// exec runs a write statement, with ExpectRows it runs in a transaction that is rolled back
+// when another number of rows was affected
+func (w *write) exec(ctx context.Context, d *Durazzo, query string, args []interface{}) (Result, error) {
+	if w.returning != nil && !d.dialect.Returning() {
+		return Result{}, fmt.Errorf("RETURNING is not supported by %s", d.dialect.Name())
+	}
+	if !w.expect {
+		return w.run(ctx, d, query, args)
+	}
+
+	var result Result
+	err := d.Transaction(ctx, func(tx *Durazzo) error {
+		var err error
+		if result, err = w.run(ctx, tx, query, args); err != nil {
+			return err
+		}
+		if result.RowsAffected != w.expectRows {
+			return fmt.Errorf("%w: expected %d, got %d", ErrNoRowsAffected, w.expectRows, result.RowsAffected)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func (w *write) run(ctx context.Context, d *Durazzo, query string, args []interface{}) (Result, error) {
+	if w.returning != nil {
+		rows, err := d.conn.QueryContext(ctx, query+" RETURNING *", args...)
+		if err != nil {
+			return Result{}, err
+		}
+		defer func(rows *sql.Rows) {
+			_ = rows.Close()
+		}(rows)
+
+		affected, err := scanReturning(rows, w.returning, d.naming)
+		return Result{RowsAffected: affected}, err
+	}
+
+	sqlResult, err := d.conn.ExecContext(ctx, query, args...)
+	if err != nil {
+		return Result{}, err
+	}
+	affected, err := sqlResult.RowsAffected()
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read the affected rows: %w", err)
+	}
+	return Result{RowsAffected: affected}, nil
+}
+
+// scanReturning scans the rows of a RETURNING clause into a pointer to a struct or to a slice,
+// a struct receives the first row. It returns the number of rows.
+func scanReturning(rows *sql.Rows, model interface{}, naming NamingStrategy) (int64, error) {
+	modelValue := reflect.ValueOf(model)
+	if modelValue.Kind() != reflect.Ptr || modelValue.IsNil() {
+		return 0, errors.New("returning model must be a non-nil pointer to a struct or a slice")
+	}
+	target := modelValue.Elem()
+	if target.Kind() == reflect.Slice {
+		target.SetLen(0)
+	}
+
+	var count int64
+	for rows.Next() {
+		count++
+		switch target.Kind() {
+		case reflect.Slice:
+			element := reflect.New(target.Type().Elem()).Elem()
+			if err := util.ScanRow(rows, element, naming); err != nil {
+				return count, err
+			}
+			target.Set(reflect.Append(target, element))
+		default:
+			if count > 1 {
+				continue
+			}
+			if err := util.ScanRow(rows, target, naming); err != nil {
+				return count, err
+			}
+		}
+	}
+	return count, rows.Err()
+}
@@ -0,0 +1,138 @@
+package durazzo
+
+import (
+	"errors"
+	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/util"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// Save initializes an UPDATE writing every non-key column of model to the row with its primary key,
+// the auto update time fields of model are set to the current time first
+func (d *Durazzo) Save(model interface{}) *UpdateType {
+	ut := d.Update(model)
+	modelValue, fields, err := d.modelFields(model)
+	if err != nil {
+		ut.err = fmt.Errorf("failed to initialize Save: %w", err)
+		return ut
+	}
+
+	now := time.Now()
+	for _, field := range fields {
+		if field.IsAutoUpdateTime() {
+			util.SetTime(modelValue.FieldByIndex(field.Index), now)
+		}
+		if !field.Tag.PrimaryKey {
+			ut.updates = append(ut.updates, assignment{column: field.Column, value: field.ColumnValue(modelValue)})
+		}
+	}
+	return ut.byPrimaryKey(modelValue, fields)
+}
+
+// Updates initializes an UPDATE of the row with the primary key of model. values is either a map
+// of Go field or column names to values, or a struct whose non-zero fields are written.
+func (d *Durazzo) Updates(model interface{}, values interface{}) *UpdateType {
+	ut := d.Update(model)
+	modelValue, fields, err := d.modelFields(model)
+	if err != nil {
+		ut.err = fmt.Errorf("failed to initialize Updates: %w", err)
+		return ut
+	}
+
+	switch updates := values.(type) {
+	case map[string]interface{}:
+		names := make([]string, 0, len(updates))
+		for name := range updates {
+			names = append(names, name)
+		}
+		// map order is random, sorting keeps the statement stable
+		sort.Strings(names)
+		for _, name := range names {
+			ut.Set(name, updates[name])
+		}
+	default:
+		structValue := reflect.ValueOf(values)
+		if structValue.Kind() == reflect.Ptr {
+			structValue = structValue.Elem()
+		}
+		if structValue.Kind() != reflect.Struct {
+			ut.err = fmt.Errorf("failed to initialize Updates: values must be a map or a struct, got %T", values)
+			return ut
+		}
+		valueFields, err := util.ParseFields(structValue.Type(), d.naming)
+		if err != nil {
+			ut.err = fmt.Errorf("failed to initialize Updates: %w", err)
+			return ut
+		}
+		for _, field := range valueFields {
+			value := structValue.FieldByIndex(field.Index)
+			if !field.Tag.PrimaryKey && !value.IsZero() {
+				ut.updates = append(ut.updates, assignment{column: field.Column, value: field.Serialize(value.Interface())})
+			}
+		}
+	}
+	return ut.byPrimaryKey(modelValue, fields)
+}
+
+// DeleteModel initializes a DELETE of the row with the primary key of model
+func (d *Durazzo) DeleteModel(model interface{}) *DeleteType {
+	dt := d.Delete(model)
+	modelValue, fields, err := d.modelFields(model)
+	if err != nil {
+		dt.err = fmt.Errorf("failed to initialize DeleteModel: %w", err)
+		return dt
+	}
+
+	conditions, err := primaryKeyConditions(modelValue, fields)
+	if err != nil {
+		dt.err = fmt.Errorf("failed to initialize DeleteModel: %w", err)
+		return dt
+	}
+	return dt.Filter(conditions...)
+}
+
+// byPrimaryKey restricts the UPDATE to the row with the primary key of modelValue
+func (ut *UpdateType) byPrimaryKey(modelValue reflect.Value, fields []*util.Field) *UpdateType {
+	conditions, err := primaryKeyConditions(modelValue, fields)
+	if err != nil {
+		ut.err = fmt.Errorf("failed to initialize UpdateType: %w", err)
+		return ut
+	}
+	return ut.Filter(conditions...)
+}
+
+// modelFields returns the struct a model points to together with its column fields
+func (d *Durazzo) modelFields(model interface{}) (reflect.Value, []*util.Field, error) {
+	modelValue := reflect.ValueOf(model)
+	if modelValue.Kind() != reflect.Ptr || modelValue.IsNil() || modelValue.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, nil, errors.New("model must be a non-nil pointer to a struct")
+	}
+	modelValue = modelValue.Elem()
+
+	fields, err := util.ParseFields(modelValue.Type(), d.naming)
+	if err != nil {
+		return reflect.Value{}, nil, err
+	}
+	return modelValue, fields, nil
+}
+
+// primaryKeyConditions matches the row of a struct by every primary key column, a zero key matches no row
+func primaryKeyConditions(modelValue reflect.Value, fields []*util.Field) ([]Condition, error) {
+	var conditions []Condition
+	for _, field := range fields {
+		if !field.Tag.PrimaryKey {
+			continue
+		}
+		value := modelValue.FieldByIndex(field.Index)
+		if value.IsZero() {
+			return nil, fmt.Errorf("primary key %s of %s is not set", field.Name, modelValue.Type().Name())
+		}
+		conditions = append(conditions, Eq(field.Column, value.Interface()))
+	}
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("%s has no primary key", modelValue.Type().Name())
+	}
+	return conditions, nil
+}
@@ -1,7 +1,9 @@
 package durazzo
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/EraldCaka/durazzo/pkg/util"
@@ -14,49 +16,124 @@ import (
 // SelectType handles SELECT queries and is created by Durazzo
 type SelectType struct {
 	*Durazzo
-	modelType    reflect.Type
-	tableName    string
-	model        interface{}
-	conditions   []string
-	args         []interface{}
-	limit        int
-	isPointer    bool
-	queryBuilder QueryBuilder
+	modelType     reflect.Type
+	tableName     string
+	model         interface{}
+	columns       []string
+	joins         []JoinClause
+	conditions    []Condition
+	groupBy       []string
+	having        []Condition
+	aggregates    []string
+	orders        []Order
+	after         []interface{}
+	afterToken    []json.RawMessage
+	limit         int
+	offset        int
+	isPointer     bool
+	queryBuilder  QueryBuilder
+	nextPageToken string
+	softDelete    softDelete
+	preloads      []string
+	err           error
+}
+
+// Order is a single ORDER BY key
+type Order struct {
+	Column    string
+	Direction string
+}
+
+// SelectQuery carries the clauses of a SELECT statement, its conditions are already rendered
+type SelectQuery struct {
+	TableName  string
+	Columns    []string
+	Joins      []JoinClause
+	Conditions []string
+	GroupBy    []string
+	Having     []string
+	Orders     []Order
+	Limit      int
+	Offset     int
 }
 
 // QueryBuilder defines methods to construct SQL queries
 type QueryBuilder interface {
-	BuildSelectQuery(tableName string, conditions []string, limit int) (string, error)
+	BuildSelectQuery(query SelectQuery) (string, error)
 }
 
-type SQLQueryBuilder struct{}
+type SQLQueryBuilder struct {
+	dialect Dialect
+}
 
-func (qb *SQLQueryBuilder) BuildSelectQuery(tableName string, conditions []string, limit int) (string, error) {
-	if tableName == "" {
+func (qb *SQLQueryBuilder) BuildSelectQuery(query SelectQuery) (string, error) {
+	if query.TableName == "" {
 		return "", errors.New("table name cannot be empty")
 	}
 
+	projection := "*"
+	if len(query.Columns) > 0 {
+		columns := make([]string, len(query.Columns))
+		for i, column := range query.Columns {
+			columns[i] = quoteColumn(qb.dialect, column)
+		}
+		projection = strings.Join(columns, ", ")
+	}
+
 	var queryBuilder strings.Builder
-	queryBuilder.WriteString(fmt.Sprintf(`SELECT * FROM "%s"`, tableName))
+	queryBuilder.WriteString(fmt.Sprintf(`SELECT %s FROM %s`, projection, quoteTable(qb.dialect, query.TableName)))
+
+	for _, join := range query.Joins {
+		queryBuilder.WriteString(" " + join.render(qb.dialect))
+	}
 
-	if len(conditions) > 0 {
-		queryBuilder.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	if len(query.Conditions) > 0 {
+		queryBuilder.WriteString(" WHERE " + strings.Join(query.Conditions, " AND "))
 	}
 
-	if limit > 0 {
-		queryBuilder.WriteString(fmt.Sprintf(" LIMIT %d", limit))
+	if len(query.GroupBy) > 0 {
+		columns := make([]string, len(query.GroupBy))
+		for i, column := range query.GroupBy {
+			columns[i] = quoteColumn(qb.dialect, column)
+		}
+		queryBuilder.WriteString(" GROUP BY " + strings.Join(columns, ", "))
+	}
+
+	if len(query.Having) > 0 {
+		queryBuilder.WriteString(" HAVING " + strings.Join(query.Having, " AND "))
+	}
+
+	if len(query.Orders) > 0 {
+		orders := make([]string, len(query.Orders))
+		for i, order := range query.Orders {
+			orders[i] = quoteColumn(qb.dialect, order.Column) + " " + order.Direction
+		}
+		queryBuilder.WriteString(" ORDER BY " + strings.Join(orders, ", "))
+	}
+
+	if query.Limit > 0 || query.Offset > 0 {
+		queryBuilder.WriteString(" " + qb.dialect.LimitOffset(query.Limit, query.Offset))
 	}
 
 	return queryBuilder.String(), nil
 }
 
 // Select initializes a SELECT operation from Durazzo it receives a pointer of an interface
-// MUST be a pointer of a type
+// MUST be a pointer of a type. A pointer to a map[string]interface{} or to a slice of them
+// receives the rows keyed by column, their table is named with Table.
 func (d *Durazzo) Select(model interface{}) *SelectType {
-	modelType, tableName, isPointer, err := util.ResolveModelInfo(model)
+	var modelType reflect.Type
+	var tableName string
+	var isPointer bool
+	var err error
+	if mapType, ok := util.MapModelType(model); ok {
+		modelType = mapType
+	} else {
+		modelType, tableName, isPointer, err = util.ResolveModelInfo(model, d.naming)
+	}
 
 	if err != nil {
-		log.Println("failed to initialize SelectType: ", err)
+		err = fmt.Errorf("failed to initialize SelectType: %w", err)
 	}
 
 	return &SelectType{
@@ -64,18 +141,47 @@ func (d *Durazzo) Select(model interface{}) *SelectType {
 		modelType:    modelType,
 		tableName:    tableName,
 		model:        model,
-		conditions:   []string{},
-		args:         []interface{}{},
+		conditions:   []Condition{},
 		limit:        0,
 		isPointer:    isPointer,
-		queryBuilder: &SQLQueryBuilder{},
+		queryBuilder: &SQLQueryBuilder{dialect: d.dialect},
+		softDelete:   d.softDelete(modelType),
+		err:          err,
 	}
 }
 
-// Where adds a where condition inside the query
-func (st *SelectType) Where(field, value string) *SelectType {
-	st.conditions = append(st.conditions, fmt.Sprintf(`%s = $%d`, field, len(st.args)+1))
-	st.args = append(st.args, value)
+// Table reads from table instead of the table of the model
+func (st *SelectType) Table(table string) *SelectType {
+	st.tableName = table
+	return st
+}
+
+// Columns restricts the query to the given columns, fields of other columns are left zeroed
+func (st *SelectType) Columns(columns ...string) *SelectType {
+	st.columns = append(st.columns, columns...)
+	return st
+}
+
+// Where adds an equality condition inside the query
+func (st *SelectType) Where(field string, value interface{}) *SelectType {
+	st.conditions = append(st.conditions, Eq(field, value))
+	return st
+}
+
+// Filter adds conditions inside the query, they are joined by AND
+func (st *SelectType) Filter(conditions ...Condition) *SelectType {
+	st.conditions = append(st.conditions, conditions...)
+	return st
+}
+
+// OrderBy adds a sort key to the query, direction is either Asc or Desc
+func (st *SelectType) OrderBy(column, direction string) *SelectType {
+	direction = strings.ToUpper(strings.TrimSpace(direction))
+	if direction != Asc && direction != Desc {
+		st.err = fmt.Errorf("invalid order direction %q for column %s", direction, column)
+		return st
+	}
+	st.orders = append(st.orders, Order{Column: column, Direction: direction})
 	return st
 }
 
@@ -85,20 +191,35 @@ func (st *SelectType) Limit(limit int) *SelectType {
 	return st
 }
 
+// Offset skips the first offset rows of the result
+func (st *SelectType) Offset(offset int) *SelectType {
+	st.offset = offset
+	return st
+}
+
 // Run executes the query asynchronously using a dedicated channel
 func (st *SelectType) Run() error {
+	return st.RunContext(context.Background())
+}
+
+// RunContext executes the query asynchronously and returns ctx.Err() once ctx is done and the query aborted
+func (st *SelectType) RunContext(ctx context.Context) error {
+	if st.err != nil {
+		return st.err
+	}
+
 	resultChan := make(chan error, 1)
 	go func() {
 		startTime := time.Now()
 
-		query, err := st.queryBuilder.BuildSelectQuery(st.tableName, st.conditions, st.limit)
+		query, args, err := st.build()
 		if err != nil {
 			resultChan <- err
 			close(resultChan)
 			return
 		}
 
-		rows, err := st.Durazzo.Db.Query(query, st.args...)
+		rows, err := st.Durazzo.conn.QueryContext(ctx, query, args...)
 		if err != nil {
 			resultChan <- err
 			close(resultChan)
@@ -110,12 +231,136 @@ func (st *SelectType) Run() error {
 			}
 		}(rows)
 
-		err = util.MapRowsToModel(rows, st.model, st.modelType, st.isPointer)
+		if st.modelType.Kind() == reflect.Map {
+			err = util.MapRowsToMaps(rows, st.model)
+		} else {
+			err = util.MapRowsToModel(rows, st.model, st.modelType, st.isPointer, st.naming)
+		}
+		if err == nil && len(st.orders) > 0 {
+			st.nextPageToken, err = st.buildNextPageToken()
+		}
 		elapsedTime := time.Since(startTime)
 		log.Printf("Query : %s took %v to run\n\n", query, elapsedTime)
 
 		resultChan <- err
 		close(resultChan)
 	}()
-	return <-resultChan
+
+	select {
+	case err := <-resultChan:
+		if err == nil && len(st.preloads) > 0 {
+			err = st.preload(ctx)
+		}
+		return err
+	case <-ctx.Done():
+		// QueryContext aborts the query once ctx is done, waiting for the goroutine keeps it
+		// from writing into the model after RunContext returned
+		<-resultChan
+		return ctx.Err()
+	}
+}
+
+// build renders the SELECT statement and its arguments
+func (st *SelectType) build() (string, []interface{}, error) {
+	query, args, err := st.selectQuery()
+	if err != nil {
+		return "", nil, err
+	}
+	rendered, err := st.queryBuilder.BuildSelectQuery(query)
+	return rendered, args, err
+}
+
+// selectQuery collects the clauses of the SELECT statement and renders its conditions
+func (st *SelectType) selectQuery() (SelectQuery, []interface{}, error) {
+	query, args, err := st.filteredQuery()
+	if err != nil {
+		return SelectQuery{}, nil, err
+	}
+	if query.Columns, err = st.projection(); err != nil {
+		return SelectQuery{}, nil, err
+	}
+	return query, args, nil
+}
+
+// projection returns the selected columns, the model's own columns are qualified by its table
+// when tables are joined and aggregates follow the columns identifying their groups
+func (st *SelectType) projection() ([]string, error) {
+	columns := st.columns
+	if len(st.joins) > 0 && len(columns) == 0 && len(st.groupBy) == 0 && len(st.aggregates) == 0 && st.modelType.Kind() == reflect.Struct {
+		var err error
+		if columns, err = st.joinedColumns(); err != nil {
+			return nil, err
+		}
+	}
+	if len(st.aggregates) > 0 {
+		if len(columns) == 0 {
+			// grouped rows are identified by their group columns
+			columns = st.groupBy
+		}
+		columns = append(columns[:len(columns):len(columns)], st.aggregates...)
+	}
+	return columns, nil
+}
+
+// filteredQuery collects the clauses of the SELECT statement but its columns and renders its conditions
+func (st *SelectType) filteredQuery() (SelectQuery, []interface{}, error) {
+	softDelete := st.softDelete
+	if len(st.joins) > 0 && softDelete.column != "" {
+		// joined tables share column names
+		softDelete.column = st.tableName + "." + softDelete.column
+	}
+
+	conditions := softDelete.scope(st.conditions)
+	after := st.after
+	if st.afterToken != nil {
+		var err error
+		if after, err = st.pageTokenValues(); err != nil {
+			return SelectQuery{}, nil, err
+		}
+	}
+	if after != nil {
+		conditions = append(conditions[:len(conditions):len(conditions)], &keysetCondition{orders: st.orders, values: after})
+	}
+
+	// the joins precede the WHERE clause, their arguments are bound first
+	var args []interface{}
+	joins := make([]JoinClause, len(st.joins))
+	for i, join := range st.joins {
+		var err error
+		if joins[i], err = join.bind(st.dialect, &args); err != nil {
+			return SelectQuery{}, nil, err
+		}
+	}
+	renderedConditions, err := renderConditions(st.dialect, conditions, &args)
+	if err != nil {
+		return SelectQuery{}, nil, err
+	}
+	renderedHaving, err := renderConditions(st.dialect, st.having, &args)
+	if err != nil {
+		return SelectQuery{}, nil, err
+	}
+
+	return SelectQuery{
+		TableName:  st.tableName,
+		Joins:      joins,
+		Conditions: renderedConditions,
+		GroupBy:    st.groupBy,
+		Having:     renderedHaving,
+		Orders:     st.orders,
+		Limit:      st.limit,
+		Offset:     st.offset,
+	}, args, nil
+}
+
+// renderConditions renders every condition on its own, the query builder joins them
+func renderConditions(dialect Dialect, conditions []Condition, args *[]interface{}) ([]string, error) {
+	rendered := make([]string, len(conditions))
+	for i, condition := range conditions {
+		conditionSQL, err := condition.build(dialect, args)
+		if err != nil {
+			return nil, err
+		}
+		rendered[i] = conditionSQL
+	}
+	return rendered, nil
 }
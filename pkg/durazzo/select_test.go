@@ -1,6 +1,7 @@
 package durazzo_test
 
 import (
+	"context"
 	"github.com/EraldCaka/durazzo/pkg/durazzo"
 	"github.com/stretchr/testify/assert"
 	"log"
@@ -185,3 +186,216 @@ func insertTestData(t *testing.T, db *durazzo.Durazzo) {
 		assert.Nil(t, err, "Error inserting user data: %v", err)
 	}
 }
+
+func TestDurazzo_Select_RunContextCanceled(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var users []User
+	err := newDurazzo.Select(&users).RunContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	err = newDurazzo.Insert(&User{ID: 1, Name: "edgar", Email: "edgar@gmail.com"}).RunContext(ctx)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDurazzo_Select_Columns(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+	insertConditionUsers(t, newDurazzo)
+
+	var users []User
+	err := newDurazzo.Select(&users).Columns("id", "name").Where("name", "kris").Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(users))
+	assert.Equal(t, 1, users[0].ID)
+	assert.Equal(t, "kris", users[0].Name)
+	assert.Equal(t, "", users[0].Email)
+
+	user := User{ID: 9, Name: "stale", Email: "stale@gmail.com"}
+	err = newDurazzo.Select(&user).Columns("name").Where("id", 2).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, User{Name: "erald"}, user)
+}
+
+func TestDurazzo_Select_ScansByColumnName(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	_, err := newDurazzo.Db.Exec(`DROP TABLE "user"`)
+	assert.Nil(t, err)
+	_, err = newDurazzo.Db.Exec(`CREATE TABLE "user" ("email" TEXT, "nickname" TEXT, "name" TEXT, "id" INTEGER PRIMARY KEY)`)
+	assert.Nil(t, err)
+	_, err = newDurazzo.Db.Exec(`INSERT INTO "user" ("email", "nickname", "name", "id") VALUES ('kris@yahoo.com', 'k', 'kris', 7)`)
+	assert.Nil(t, err)
+
+	var users []User
+	err = newDurazzo.Select(&users).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []User{{ID: 7, Name: "kris", Email: "kris@yahoo.com"}}, users)
+}
+
+type BookWithAuthor struct {
+	Book
+	Author Author `durazzo:"embedded_prefix:author_"`
+}
+
+func (BookWithAuthor) TableName() string {
+	return "book"
+}
+
+type AuthorTitle struct {
+	Name  string
+	Title *string
+}
+
+func (AuthorTitle) TableName() string {
+	return "author"
+}
+
+func TestDurazzo_Select_Join(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	err := newDurazzo.AutoMigrate(&Author{}, &Book{})
+	assert.Nil(t, err)
+	authors := []Author{{Name: "kris"}, {Name: "erald"}}
+	err = newDurazzo.Insert(authors).Run()
+	assert.Nil(t, err)
+	books := []Book{{Title: "first", AuthorID: authors[0].ID}, {Title: "second", AuthorID: authors[0].ID}}
+	err = newDurazzo.Insert(books).Run()
+	assert.Nil(t, err)
+
+	var rows []BookWithAuthor
+	err = newDurazzo.Select(&rows).
+		Join("author", "author.id = book.authorid").
+		Filter(durazzo.Eq("author.name", "kris")).
+		OrderBy("book.id", durazzo.Desc).
+		Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []BookWithAuthor{
+		{Book: books[1], Author: Author{ID: authors[0].ID, Name: "kris"}},
+		{Book: books[0], Author: Author{ID: authors[0].ID, Name: "kris"}},
+	}, rows)
+
+	var aliased []BookWithAuthor
+	err = newDurazzo.Select(&aliased).Join("author a", "a.id = book.authorid AND a.name <> 'a.id'").Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(aliased))
+	assert.Equal(t, "kris", aliased[0].Author.Name)
+
+	var bound []BookWithAuthor
+	err = newDurazzo.Select(&bound).
+		Join("author", `author.id = book.authorid AND "author"."name" = ?`, "erald").
+		Filter(durazzo.Eq("book.title", "first")).
+		Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(bound), "the book of kris does not match the bound author")
+	err = newDurazzo.Select(&bound).
+		Join("author", "author.id = book.authorid AND LOWER(author.name) = ?", "KRIS").
+		Filter(durazzo.Eq("book.title", "first")).
+		Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(bound))
+	err = newDurazzo.Select(&bound).
+		Join("author", "author.id = book.authorid AND LOWER(author.name) = LOWER(?)", "KRIS").
+		Filter(durazzo.Eq("book.title", "first")).
+		Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []BookWithAuthor{{Book: books[0], Author: Author{ID: authors[0].ID, Name: "kris"}}}, bound)
+
+	err = newDurazzo.Select(&bound).Join("author", "author.id = book.authorid AND author.name = ?").Run()
+	assert.NotNil(t, err, "every placeholder needs an argument")
+	err = newDurazzo.Select(&bound).Join("author", "author.id = book.authorid", "kris").Run()
+	assert.NotNil(t, err, "every argument needs a placeholder")
+
+	var titles []AuthorTitle
+	err = newDurazzo.Select(&titles).
+		Columns("author.name", "book.title AS title").
+		LeftJoin("book", "book.authorid = author.id").
+		OrderBy("author.id", durazzo.Asc).
+		Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(titles))
+	assert.Equal(t, "first", *titles[0].Title)
+	assert.Equal(t, "erald", titles[2].Name)
+	assert.Nil(t, titles[2].Title, "the author without books is kept by the LEFT JOIN")
+
+	err = newDurazzo.Select(&rows).Join("review", "review.bookid = book.id").Run()
+	assert.NotNil(t, err, "the table of the prefixed struct must be joined")
+	err = newDurazzo.AutoMigrate(&Review{})
+	assert.Nil(t, err)
+	err = newDurazzo.Insert(&Review{BookID: books[0].ID, Stars: 4}).Run()
+	assert.Nil(t, err)
+	count, err := newDurazzo.Select(&rows).Join("review", "review.bookid = book.id").Count(context.Background())
+	assert.Nil(t, err, "counting needs none of the columns of the prefixed struct")
+	assert.Equal(t, int64(1), count)
+}
+
+type BookStars struct {
+	BookID   int
+	SumStars int `durazzo:"column:sum_stars"`
+}
+
+func TestDurazzo_Select_Aggregates(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+	ctx := context.Background()
+
+	err := newDurazzo.AutoMigrate(&Review{})
+	assert.Nil(t, err)
+	reviews := []Review{{BookID: 1, Stars: 5}, {BookID: 1, Stars: 3}, {BookID: 2, Stars: 4}, {BookID: 3, Stars: 1}, {BookID: 3, Stars: 2}}
+	err = newDurazzo.Insert(reviews).Run()
+	assert.Nil(t, err)
+
+	count, err := newDurazzo.Select(&Review{}).Filter(durazzo.Gt("stars", 2)).Count(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), count)
+	count, err = newDurazzo.Select(&Review{}).GroupBy("bookid").Having(durazzo.Gt("COUNT(*)", 1)).Count(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), count, "grouped queries count their groups")
+
+	var stars []BookStars
+	err = newDurazzo.Select(&stars).Table("review").
+		GroupBy("bookid").
+		Sum("stars").
+		Having(durazzo.Lt("SUM(stars)", 4)).
+		OrderBy("bookid", durazzo.Asc).
+		Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []BookStars{{BookID: 3, SumStars: 3}}, stars)
+
+	var totals map[string]interface{}
+	err = newDurazzo.Select(&totals).Table("review").Min("stars").Max("stars").Avg("stars").Filter(durazzo.Eq("bookid", 1)).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"min_stars": int64(3), "max_stars": int64(5), "avg_stars": 4.0}, totals)
+
+	var grouped []map[string]interface{}
+	err = newDurazzo.Select(&grouped).Table("review").GroupBy("bookid").Max("stars").OrderBy("bookid", durazzo.Desc).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 3, len(grouped))
+	assert.Equal(t, map[string]interface{}{"bookid": int64(3), "max_stars": int64(2)}, grouped[0])
+
+	_, err = newDurazzo.Select(&grouped).Count(ctx)
+	assert.NotNil(t, err, "map models need a table")
+	err = newDurazzo.Select(&grouped).Table("review").GroupBy("bookid").Sum("stars*2").Run()
+	assert.ErrorContains(t, err, "expects a column")
+
+	err = newDurazzo.AutoMigrate(&Step{})
+	assert.Nil(t, err)
+	err = newDurazzo.Insert([]Step{{Group: "a", Order: 1}, {Group: "a", Order: 2}, {Group: "b", Order: 1}}).Run()
+	assert.Nil(t, err)
+	var steps []map[string]interface{}
+	err = newDurazzo.Select(&steps).Table("step").
+		GroupBy("group").
+		Max("order").
+		Having(durazzo.Gt("MAX(order)", 1), durazzo.Eq("count(distinct step.order)", 2)).
+		Run()
+	assert.Nil(t, err, "the columns of aggregates are quoted")
+	assert.Equal(t, []map[string]interface{}{{"group": "a", "max_order": int64(2)}}, steps)
+}
+
+// Step has columns named after reserved words
+type Step struct {
+	ID    int `durazzo:"primary_key"`
+	Group string
+	Order int
+}
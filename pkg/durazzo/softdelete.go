@@ -0,0 +1,59 @@
+package durazzo
+
+import (
+	"github.com/EraldCaka/durazzo/pkg/util"
+	"reflect"
+)
+
+// DeletedAt is the soft delete column of a model. Models with a DeletedAt field, or a time field
+// tagged soft_delete, are deleted by setting it and their deleted rows are hidden from queries.
+type DeletedAt = util.DeletedAt
+
+// softDelete holds the soft delete column of a builder's model, unscoped builders ignore it
+type softDelete struct {
+	column   string
+	unscoped bool
+}
+
+func (d *Durazzo) softDelete(modelType reflect.Type) softDelete {
+	if field := util.SoftDeleteField(modelType, d.naming); field != nil {
+		return softDelete{column: field.Column}
+	}
+	return softDelete{}
+}
+
+// active reports whether deleted rows are to be filtered out
+func (sd softDelete) active() bool {
+	return sd.column != "" && !sd.unscoped
+}
+
+// scope appends the condition hiding soft deleted rows
+func (sd softDelete) scope(conditions []Condition) []Condition {
+	if !sd.active() {
+		return conditions
+	}
+	return append(conditions[:len(conditions):len(conditions)], IsNull(sd.column))
+}
+
+// Unscoped includes soft deleted rows in the result
+func (st *SelectType) Unscoped() *SelectType {
+	st.softDelete.unscoped = true
+	return st
+}
+
+// Unscoped lets the UPDATE change soft deleted rows as well, e.g. to restore them
+func (ut *UpdateType) Unscoped() *UpdateType {
+	ut.softDelete.unscoped = true
+	return ut
+}
+
+// Unscoped deletes the rows for real even if the model soft deletes them, like HardDelete
+func (dt *DeleteType) Unscoped() *DeleteType {
+	dt.softDelete.unscoped = true
+	return dt
+}
+
+// HardDelete removes the rows from the table even if the model soft deletes them
+func (dt *DeleteType) HardDelete() *DeleteType {
+	return dt.Unscoped()
+}
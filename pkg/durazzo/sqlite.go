@@ -2,6 +2,9 @@ package durazzo
 
 import (
 	"database/sql"
+	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/util"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
@@ -9,3 +12,92 @@ import (
 func initSQLite(dsn string) (*sql.DB, error) {
 	return sql.Open("sqlite3", dsn)
 }
+
+type sqliteDialect struct{}
+
+func (s *sqliteDialect) Name() string {
+	return Sqlite
+}
+
+func (s *sqliteDialect) Placeholder(int) string {
+	return "?"
+}
+
+func (s *sqliteDialect) Quote(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+// DataType declares times as DATETIME, the type name the driver converts back into time.Time, and JSON as TEXT
+func (s *sqliteDialect) DataType(column Column) string {
+	if column.JSON {
+		return "TEXT"
+	}
+	if util.IsTimeType(column.Type) {
+		return "DATETIME"
+	}
+	return baseDataType(column)
+}
+
+// AutoIncrement uses INTEGER since SQLite only aliases the rowid for that exact type name
+func (s *sqliteDialect) AutoIncrement(Column) string {
+	return "INTEGER PRIMARY KEY AUTOINCREMENT"
+}
+
+func (s *sqliteDialect) CaseInsensitiveLike(column, placeholder string) string {
+	return fmt.Sprintf("LOWER(%s) LIKE LOWER(%s)", column, placeholder)
+}
+
+func (s *sqliteDialect) LimitOffset(limit, offset int) string {
+	if limit <= 0 && offset > 0 {
+		// SQLite cannot OFFSET without a LIMIT, a negative limit means no limit
+		return fmt.Sprintf("LIMIT -1 OFFSET %d", offset)
+	}
+	return limitOffset(limit, offset)
+}
+
+// TableExistsQuery reads the schema table of an attached database when schema is set
+func (s *sqliteDialect) TableExistsQuery(schema, table string) (string, []interface{}) {
+	master := "sqlite_master"
+	if schema != "" {
+		master = s.Quote(schema) + ".sqlite_master"
+	}
+	return "SELECT COUNT(*) FROM " + master + " WHERE type = 'table' AND name = ?", []interface{}{table}
+}
+
+func (s *sqliteDialect) TransactionalDDL() bool {
+	return true
+}
+
+// MigrationLock is not needed since SQLite only lets a single writer in at a time
+func (s *sqliteDialect) MigrationLock(string) (string, string, []interface{}) {
+	return "", "", nil
+}
+
+// ColumnsQuery uses the table_info pragma, its optional second argument names the attached database
+func (s *sqliteDialect) ColumnsQuery(schema, table string) (string, []interface{}) {
+	if schema == "" {
+		return "SELECT name FROM pragma_table_info(?)", []interface{}{table}
+	}
+	return "SELECT name FROM pragma_table_info(?, ?)", []interface{}{table, schema}
+}
+
+func (s *sqliteDialect) IndexesQuery(schema, table string) (string, []interface{}) {
+	if schema == "" {
+		return "SELECT name FROM pragma_index_list(?)", []interface{}{table}
+	}
+	return "SELECT name FROM pragma_index_list(?, ?)", []interface{}{table, schema}
+}
+
+// MaxParameters is SQLITE_MAX_VARIABLE_NUMBER of the bundled SQLite, builds before 3.32 stop at 999
+func (s *sqliteDialect) MaxParameters() int {
+	return 32766
+}
+
+// Returning is supported since SQLite 3.35
+func (s *sqliteDialect) Returning() bool {
+	return true
+}
+
+func (s *sqliteDialect) OnConflict(conflict, update, _ []string) string {
+	return onConflict(conflict, update)
+}
@@ -0,0 +1,90 @@
+package durazzo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// executor is the part of *sql.DB and *sql.Tx the builders run their queries on
+type executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// txBeginner is implemented by the executors transactions can start from, *sql.DB and *sql.Conn
+type txBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// Transaction runs fn inside a database transaction, the Durazzo passed to fn binds every builder to it.
+// The transaction is committed when fn returns nil and rolled back when it returns an error or panics.
+// Calling Transaction on a transactional Durazzo creates a savepoint instead of a new transaction.
+func (d *Durazzo) Transaction(ctx context.Context, fn func(tx *Durazzo) error) (err error) {
+	if d.tx != nil {
+		return d.savepoint(ctx, fn)
+	}
+
+	beginner, ok := d.conn.(txBeginner)
+	if !ok {
+		return fmt.Errorf("transactions cannot be started from %T", d.conn)
+	}
+	sqlTx, err := beginner.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	tx := *d
+	tx.conn = sqlTx
+	tx.tx = sqlTx
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = sqlTx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(&tx); err != nil {
+		if rollbackErr := sqlTx.Rollback(); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+
+	if err = sqlTx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return nil
+}
+
+// savepoint runs a nested transaction, the SAVEPOINT syntax is shared by every supported dialect
+func (d *Durazzo) savepoint(ctx context.Context, fn func(tx *Durazzo) error) (err error) {
+	nested := *d
+	nested.savepoints++
+	name := d.dialect.Quote(fmt.Sprintf("sp_%d", nested.savepoints))
+
+	if _, err = d.conn.ExecContext(ctx, "SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to create savepoint: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_, _ = d.conn.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+			panic(p)
+		}
+	}()
+
+	if err = fn(&nested); err != nil {
+		if _, rollbackErr := d.conn.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name); rollbackErr != nil {
+			return fmt.Errorf("%w (rollback to savepoint failed: %v)", err, rollbackErr)
+		}
+		return err
+	}
+
+	if _, err = d.conn.ExecContext(ctx, "RELEASE SAVEPOINT "+name); err != nil {
+		return fmt.Errorf("failed to release savepoint: %w", err)
+	}
+	return nil
+}
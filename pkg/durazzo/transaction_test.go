@@ -0,0 +1,81 @@
+package durazzo_test
+
+import (
+	"context"
+	"errors"
+	"github.com/EraldCaka/durazzo/pkg/durazzo"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDurazzo_Transaction_Commit(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	err := newDurazzo.Transaction(context.Background(), func(tx *durazzo.Durazzo) error {
+		if err := tx.Insert(&User{ID: 1, Name: "edgar", Email: "edgar@gmail.com"}).Run(); err != nil {
+			return err
+		}
+		return tx.Update("user").Set("name", "kris").Where("id", "1").Run()
+	})
+	assert.Nil(t, err)
+
+	var users []User
+	err = newDurazzo.Select(&users).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(users))
+	assert.Equal(t, "kris", users[0].Name)
+}
+
+func TestDurazzo_Transaction_Rollback(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+	errAbort := errors.New("abort")
+
+	err := newDurazzo.Transaction(context.Background(), func(tx *durazzo.Durazzo) error {
+		if err := tx.Insert(&User{ID: 1, Name: "edgar", Email: "edgar@gmail.com"}).Run(); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	assert.ErrorIs(t, err, errAbort)
+
+	assert.Panics(t, func() {
+		_ = newDurazzo.Transaction(context.Background(), func(tx *durazzo.Durazzo) error {
+			_ = tx.Insert(&User{ID: 2, Name: "kris", Email: "kris@yahoo.com"}).Run()
+			panic("boom")
+		})
+	})
+
+	var users []User
+	err = newDurazzo.Select(&users).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(users))
+}
+
+func TestDurazzo_Transaction_NestedSavepoint(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	err := newDurazzo.Transaction(context.Background(), func(tx *durazzo.Durazzo) error {
+		if err := tx.Insert(&User{ID: 1, Name: "edgar", Email: "edgar@gmail.com"}).Run(); err != nil {
+			return err
+		}
+		nestedErr := tx.Transaction(context.Background(), func(nested *durazzo.Durazzo) error {
+			if err := nested.Insert(&User{ID: 2, Name: "kris", Email: "kris@yahoo.com"}).Run(); err != nil {
+				return err
+			}
+			return errors.New("discard kris")
+		})
+		assert.NotNil(t, nestedErr)
+
+		return tx.Transaction(context.Background(), func(nested *durazzo.Durazzo) error {
+			return nested.Insert(&User{ID: 3, Name: "sara", Email: "sara@hotmail.com"}).Run()
+		})
+	})
+	assert.Nil(t, err)
+
+	var users []User
+	err = newDurazzo.Select(&users).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(users))
+	assert.Equal(t, "edgar", users[0].Name)
+	assert.Equal(t, "sara", users[1].Name)
+}
@@ -0,0 +1,50 @@
+package durazzo
+
+import (
+	"reflect"
+	"sync"
+)
+
+var (
+	columnTypesMu sync.RWMutex
+	columnTypes   = map[reflect.Type]map[string]string{}
+)
+
+// RegisterColumnType sets the DDL AutoMigrate uses for fields of the type of value, keyed by driver
+// name, e.g. RegisterColumnType(Money{}, map[string]string{Postgres: "NUMERIC(12,2)", Sqlite: "TEXT"}).
+// The empty key applies to every driver without an entry of its own. The type is usually a
+// sql.Scanner and driver.Valuer so that the driver can read and write it.
+func RegisterColumnType(value interface{}, ddl map[string]string) {
+	columnTypesMu.Lock()
+	defer columnTypesMu.Unlock()
+
+	types := make(map[string]string, len(ddl))
+	for driver, sqlType := range ddl {
+		types[driver] = sqlType
+	}
+	columnTypes[reflect.TypeOf(value)] = types
+}
+
+// UnregisterColumnType removes the DDL registered for the type of value, its fields get the dialect's type again
+func UnregisterColumnType(value interface{}) {
+	columnTypesMu.Lock()
+	defer columnTypesMu.Unlock()
+
+	delete(columnTypes, reflect.TypeOf(value))
+}
+
+// registeredColumnType returns the DDL registered for t on the given driver
+func registeredColumnType(t reflect.Type, driver string) (string, bool) {
+	columnTypesMu.RLock()
+	defer columnTypesMu.RUnlock()
+
+	types, ok := columnTypes[t]
+	if !ok {
+		return "", false
+	}
+	if sqlType, ok := types[driver]; ok {
+		return sqlType, true
+	}
+	sqlType, ok := types[""]
+	return sqlType, ok
+}
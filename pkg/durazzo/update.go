@@ -1,54 +1,153 @@
 package durazzo
 
 import (
+	"context"
 	"fmt"
+	"github.com/EraldCaka/durazzo/pkg/util"
+	"reflect"
 	"strings"
+	"time"
 )
 
 // UpdateType handles UPDATE operations
 type UpdateType struct {
 	*Durazzo
 	tableName  string
-	updates    []string
-	conditions []string
-	args       []interface{}
+	modelType  reflect.Type
+	updates    []assignment
+	conditions []Condition
+	softDelete softDelete
+	write
+	err error
 }
 
-// Update initializes an UPDATE operation
-func (d *Durazzo) Update(tableName string) *UpdateType {
+// assignment is a single column = value pair of a SET clause
+type assignment struct {
+	column string
+	value  interface{}
+}
+
+// Update initializes an UPDATE operation, target is either a table name or a model.
+// With a model, Set and Where also accept Go field names
+func (d *Durazzo) Update(target interface{}) *UpdateType {
+	tableName, modelType, err := d.resolveTarget(target)
+	if err != nil {
+		err = fmt.Errorf("failed to initialize UpdateType: %w", err)
+	}
+
 	return &UpdateType{
 		Durazzo:    d,
 		tableName:  tableName,
-		updates:    []string{},
-		conditions: []string{},
-		args:       []interface{}{},
+		modelType:  modelType,
+		softDelete: d.softDelete(modelType),
+		err:        err,
+		updates:    []assignment{},
+		conditions: []Condition{},
 	}
 }
 
 // Set adds a field-value pair to be updated
 func (ut *UpdateType) Set(field string, value interface{}) *UpdateType {
-	ut.updates = append(ut.updates, fmt.Sprintf(`%s = $%d`, field, len(ut.args)+1))
-	ut.args = append(ut.args, value)
+	column := ut.resolveColumn(ut.modelType, field)
+	ut.updates = append(ut.updates, assignment{column: column, value: ut.serialize(ut.modelType, column, value)})
+	return ut
+}
+
+// Where adds an equality condition to the UPDATE query
+func (ut *UpdateType) Where(field string, value interface{}) *UpdateType {
+	ut.conditions = append(ut.conditions, Eq(ut.resolveColumn(ut.modelType, field), value))
+	return ut
+}
+
+// Filter adds conditions to the UPDATE query, they are joined by AND
+func (ut *UpdateType) Filter(conditions ...Condition) *UpdateType {
+	ut.conditions = append(ut.conditions, conditions...)
 	return ut
 }
 
-// Where adds a condition to the UPDATE query
-func (ut *UpdateType) Where(field, value string) *UpdateType {
-	ut.conditions = append(ut.conditions, fmt.Sprintf(`%s = $%d`, field, len(ut.args)+1))
-	ut.args = append(ut.args, value)
+// ExpectRows makes the UPDATE fail with ErrNoRowsAffected and roll back unless exactly n rows change
+func (ut *UpdateType) ExpectRows(n int64) *UpdateType {
+	ut.expect = true
+	ut.expectRows = n
+	return ut
+}
+
+// Returning scans the updated rows into model, a pointer to a struct or a slice, on Postgres and SQLite
+func (ut *UpdateType) Returning(model interface{}) *UpdateType {
+	ut.returning = model
 	return ut
 }
 
 // Run executes the UPDATE query
 func (ut *UpdateType) Run() error {
+	return ut.RunContext(context.Background())
+}
+
+// RunContext executes the UPDATE query using ctx
+func (ut *UpdateType) RunContext(ctx context.Context) error {
+	_, err := ut.ExecContext(ctx)
+	return err
+}
+
+// Exec executes the UPDATE query and reports the affected rows
+func (ut *UpdateType) Exec() (Result, error) {
+	return ut.ExecContext(context.Background())
+}
+
+// ExecContext executes the UPDATE query using ctx and reports the affected rows
+func (ut *UpdateType) ExecContext(ctx context.Context) (Result, error) {
+	if ut.err != nil {
+		return Result{}, ut.err
+	}
 	if len(ut.updates) == 0 {
-		return fmt.Errorf("no updates specified for UPDATE operation")
+		return Result{}, fmt.Errorf("no updates specified for UPDATE operation")
 	}
 	if len(ut.conditions) == 0 {
-		return fmt.Errorf("no conditions specified for UPDATE operation")
+		return Result{}, fmt.Errorf("no conditions specified for UPDATE operation")
 	}
 
-	query := fmt.Sprintf(`UPDATE "%s" SET %s WHERE %s`, ut.tableName, strings.Join(ut.updates, ", "), strings.Join(ut.conditions, " AND "))
-	_, err := ut.Durazzo.Db.Exec(query, ut.args...)
-	return err
+	assignments := ut.withUpdateTimes(time.Now())
+	var args []interface{}
+	updates := make([]string, len(assignments))
+	for i, update := range assignments {
+		updates[i] = fmt.Sprintf(`%s = %s`, quoteColumn(ut.dialect, update.column), bind(ut.dialect, &args, update.value))
+	}
+
+	where, err := buildConditions(ut.dialect, ut.softDelete.scope(ut.conditions), &args)
+	if err != nil {
+		return Result{}, err
+	}
+
+	query := fmt.Sprintf(`UPDATE %s SET %s WHERE %s`, quoteTable(ut.dialect, ut.tableName), strings.Join(updates, ", "), where)
+	return ut.exec(ctx, ut.Durazzo, query, args)
+}
+
+// withUpdateTimes adds now for the auto update time columns of the model the UPDATE does not set itself
+func (ut *UpdateType) withUpdateTimes(now time.Time) []assignment {
+	if ut.modelType == nil {
+		return ut.updates
+	}
+	fields, err := util.ParseFields(ut.modelType, ut.naming)
+	if err != nil {
+		return ut.updates
+	}
+
+	assignments := ut.updates
+	for _, field := range fields {
+		if !field.IsAutoUpdateTime() || ut.assigns(field.Column) {
+			continue
+		}
+		assignments = append(assignments[:len(assignments):len(assignments)], assignment{column: field.Column, value: now})
+	}
+	return assignments
+}
+
+// assigns reports whether the SET clause already covers column
+func (ut *UpdateType) assigns(column string) bool {
+	for _, update := range ut.updates {
+		if strings.EqualFold(update.column, column) {
+			return true
+		}
+	}
+	return false
 }
@@ -1,8 +1,10 @@
 package durazzo_test
 
 import (
+	"github.com/EraldCaka/durazzo/pkg/durazzo"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 func TestDurazzo_Update(t *testing.T) {
@@ -38,3 +40,101 @@ func TestDurazzo_Update(t *testing.T) {
 	assert.Equal(t, "kris", users[0].Name)
 	assert.Equal(t, "kris@yahoo.com", users[0].Email)
 }
+
+func TestDurazzo_Save(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	err := newDurazzo.AutoMigrate(&User{})
+	assert.Nil(t, err)
+	users := []User{{Name: "kris", Email: "kris@gmail.com"}, {Name: "erald", Email: "erald@gmail.com"}}
+	err = newDurazzo.Insert(users).Run()
+	assert.Nil(t, err)
+
+	users[0].Name = "kristi"
+	users[0].Email = "kristi@gmail.com"
+	err = newDurazzo.Save(&users[0]).Run()
+	assert.Nil(t, err)
+
+	err = newDurazzo.Updates(&users[1], map[string]interface{}{"Name": "erald caka"}).Run()
+	assert.Nil(t, err)
+	err = newDurazzo.Updates(&users[1], User{Email: "erald@yahoo.com"}).Run()
+	assert.Nil(t, err)
+
+	var stored []User
+	err = newDurazzo.Select(&stored).OrderBy("id", durazzo.Asc).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, []User{
+		{ID: 1, Name: "kristi", Email: "kristi@gmail.com"},
+		{ID: 2, Name: "erald caka", Email: "erald@yahoo.com"},
+	}, stored)
+
+	err = newDurazzo.Save(&User{Name: "jessie"}).Run()
+	assert.NotNil(t, err, "a model without key cannot be saved")
+	err = newDurazzo.Updates(&users[1], "name").Run()
+	assert.NotNil(t, err)
+}
+
+func TestDurazzo_Update_Result(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+	insertConditionUsers(t, newDurazzo)
+
+	result, err := newDurazzo.Update(&User{}).Set("Name", "kris").Filter(durazzo.Gt("id", 2)).Exec()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(2), result.RowsAffected)
+
+	_, err = newDurazzo.Update(&User{}).Set("Name", "lost").Where("ID", 99).ExpectRows(1).Exec()
+	assert.ErrorIs(t, err, durazzo.ErrNoRowsAffected)
+
+	err = newDurazzo.Update(&User{}).Set("Name", "everyone").Filter(durazzo.Gt("id", 0)).ExpectRows(1).Run()
+	assert.ErrorIs(t, err, durazzo.ErrNoRowsAffected)
+	var renamed int
+	err = newDurazzo.Raw(`SELECT COUNT(*) FROM user WHERE name = ?`, "everyone").Model(&renamed).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, 0, renamed, "the guarded update is rolled back")
+
+	var updated []User
+	result, err = newDurazzo.Update(&User{}).Set("Name", "sara").Where("ID", 1).Returning(&updated).Exec()
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), result.RowsAffected)
+	assert.Equal(t, []User{{ID: 1, Name: "sara", Email: "kris@yahoo.com"}}, updated)
+}
+
+type Note struct {
+	ID        int `durazzo:"primary_key"`
+	Text      string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Reviewed  *time.Time `durazzo:"autoUpdateTime"`
+}
+
+func TestDurazzo_Update_AutoTimes(t *testing.T) {
+	newDurazzo := setupSQLiteDatabase(t)
+
+	err := newDurazzo.AutoMigrate(&Note{})
+	assert.Nil(t, err)
+
+	before := time.Now()
+	note := Note{Text: "first"}
+	err = newDurazzo.Insert(&note).Run()
+	assert.Nil(t, err)
+	assert.False(t, note.CreatedAt.Before(before))
+	assert.True(t, note.UpdatedAt.Equal(note.CreatedAt))
+	assert.NotNil(t, note.Reviewed)
+
+	created := note.CreatedAt
+	note.Text = "second"
+	err = newDurazzo.Save(&note).Run()
+	assert.Nil(t, err)
+	assert.True(t, note.UpdatedAt.After(created))
+
+	err = newDurazzo.Update(&Note{}).Set("Text", "third").Where("ID", note.ID).Run()
+	assert.Nil(t, err)
+
+	var stored Note
+	err = newDurazzo.Select(&stored).Where("id", note.ID).Run()
+	assert.Nil(t, err)
+	assert.Equal(t, "third", stored.Text)
+	assert.True(t, stored.CreatedAt.Equal(created))
+	assert.True(t, stored.UpdatedAt.After(note.UpdatedAt))
+	assert.True(t, stored.Reviewed.Equal(stored.UpdatedAt))
+}
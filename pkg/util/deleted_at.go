@@ -0,0 +1,79 @@
+package util
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+)
+
+// DeletedAt is the soft delete column of a model, it stays NULL until the row is deleted
+type DeletedAt sql.NullTime
+
+// Scan implements the sql.Scanner interface
+func (d *DeletedAt) Scan(value interface{}) error {
+	return (*sql.NullTime)(d).Scan(value)
+}
+
+// Value implements the driver.Valuer interface
+func (d DeletedAt) Value() (driver.Value, error) {
+	return sql.NullTime(d).Value()
+}
+
+var deletedAtType = reflect.TypeOf(DeletedAt{})
+
+// IsSoftDelete reports whether the field marks deleted rows, either through its DeletedAt type or the soft_delete tag
+func (f *Field) IsSoftDelete() bool {
+	return f.Tag.SoftDelete || f.Type == deletedAtType
+}
+
+// zeroTimeIsNull reports whether the field is a plain time.Time soft delete column, its zero time is stored as NULL
+// so that rows which were never deleted match the IS NULL scope
+func (f *Field) zeroTimeIsNull() bool {
+	return f.Tag.SoftDelete && f.Type == timeType
+}
+
+// nullTimeScanner scans a nullable timestamp into a time.Time field, NULL becomes the zero time
+type nullTimeScanner struct {
+	target reflect.Value
+}
+
+func (n *nullTimeScanner) Scan(src interface{}) error {
+	var value sql.NullTime
+	if err := value.Scan(src); err != nil {
+		return err
+	}
+	n.target.Set(reflect.ValueOf(value.Time))
+	return nil
+}
+
+// validateSoftDelete checks that a soft_delete field can hold both NULL and the deletion time
+func validateSoftDelete(fieldType reflect.Type, tag Tag) error {
+	if !tag.SoftDelete {
+		return nil
+	}
+	if !IsTimeType(fieldType) {
+		return fmt.Errorf("soft_delete needs a time field, got %s", fieldType)
+	}
+	if tag.NotNull {
+		return fmt.Errorf("soft_delete columns are NULL until the row is deleted, they cannot be not_null")
+	}
+	return nil
+}
+
+// SoftDeleteField returns the soft delete field of a struct type, nil when its rows are deleted for real
+func SoftDeleteField(structType reflect.Type, naming NamingStrategy) *Field {
+	if structType == nil || structType.Kind() != reflect.Struct {
+		return nil
+	}
+	fields, err := ParseFields(structType, naming)
+	if err != nil {
+		return nil
+	}
+	for _, field := range fields {
+		if field.IsSoftDelete() {
+			return field
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package util
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Tabler is implemented by models that choose their own table name
+type Tabler interface {
+	TableName() string
+}
+
+// NamingStrategy maps Go type and field names to table and column names
+type NamingStrategy interface {
+	TableName(typeName string) string
+	ColumnName(fieldName string) string
+}
+
+// Naming is the configurable NamingStrategy, its zero value lower cases names which is the default
+type Naming struct {
+	// TablePrefix is prepended to every table name derived from a type name
+	TablePrefix string
+	// Schema qualifies every table name derived from a type name, e.g. public.users
+	Schema string
+	// SnakeCase converts names to snake_case instead of lower casing them
+	SnakeCase bool
+	// PluralTables pluralizes the table names derived from type names
+	PluralTables bool
+}
+
+// DefaultNaming is used whenever no NamingStrategy is configured
+var DefaultNaming NamingStrategy = Naming{}
+
+func (n Naming) TableName(typeName string) string {
+	name := n.convert(typeName)
+	if n.PluralTables {
+		name = Pluralize(name)
+	}
+	name = n.TablePrefix + name
+	if n.Schema != "" {
+		name = n.Schema + "." + name
+	}
+	return name
+}
+
+func (n Naming) ColumnName(fieldName string) string {
+	return n.convert(fieldName)
+}
+
+func (n Naming) convert(name string) string {
+	if n.SnakeCase {
+		return SnakeCase(name)
+	}
+	return strings.ToLower(name)
+}
+
+// SnakeCase converts a Go identifier to snake_case keeping acronyms together, e.g. UserID becomes user_id
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var builder strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			previousLower := i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]))
+			acronymEnd := i > 0 && unicode.IsUpper(runes[i-1]) && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if previousLower || acronymEnd {
+				builder.WriteRune('_')
+			}
+			builder.WriteRune(unicode.ToLower(r))
+			continue
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
+// Pluralize returns the English plural of a lower case noun using the common suffix rules
+func Pluralize(name string) string {
+	switch {
+	case name == "":
+		return name
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return name[:len(name)-1] + "ies"
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "z"),
+		strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	default:
+		return name + "s"
+	}
+}
+
+// TableNameOf returns the table of a struct type, models implementing Tabler name it themselves
+func TableNameOf(structType reflect.Type, naming NamingStrategy) string {
+	if tabler, ok := reflect.New(structType).Interface().(Tabler); ok {
+		return tabler.TableName()
+	}
+	if naming == nil {
+		naming = DefaultNaming
+	}
+	return naming.TableName(structType.Name())
+}
@@ -0,0 +1,54 @@
+package util
+
+import (
+	"database/sql"
+	"reflect"
+)
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+
+// ValueType returns the type of the value a nullable type wraps: the element of a pointer
+// or the value field of sql.NullString, sql.Null[T] and similar structs. Other types are returned as is.
+func ValueType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Ptr {
+		return t.Elem()
+	}
+	if value, ok := nullStructValue(t); ok {
+		return value.Type
+	}
+	return t
+}
+
+// IsNullable reports whether the field can hold NULL, which holds for pointers, slices, maps and
+// interfaces as well as for sql.Null* like structs and soft delete fields. A not_null tag makes any field NOT NULL.
+func (f *Field) IsNullable() bool {
+	if f.Tag.NotNull || f.Tag.PrimaryKey {
+		return false
+	}
+	if f.Tag.SoftDelete {
+		return true
+	}
+	switch f.Type.Kind() {
+	case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	}
+	_, ok := nullStructValue(f.Type)
+	return ok
+}
+
+// nullStructValue recognises the sql.Null* shape, a Scanner struct holding a value and a Valid flag
+func nullStructValue(t reflect.Type) (reflect.StructField, bool) {
+	if t.Kind() != reflect.Struct || t.NumField() != 2 || !reflect.PointerTo(t).Implements(scannerType) {
+		return reflect.StructField{}, false
+	}
+	valid, ok := t.FieldByName("Valid")
+	if !ok || valid.Type.Kind() != reflect.Bool {
+		return reflect.StructField{}, false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Name != "Valid" {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
@@ -9,52 +9,47 @@ import (
 	"strings"
 )
 
-// ResolveModelInfo extracts model information for table and type resolution
-func ResolveModelInfo(model interface{}) (reflect.Type, string, bool, error) {
+// ResolveModelInfo extracts model information for table and type resolution, table names follow naming
+// unless the model implements Tabler
+func ResolveModelInfo(model interface{}, naming NamingStrategy) (reflect.Type, string, bool, error) {
 	modelType := reflect.TypeOf(model)
-	var tableName string
+	if modelType == nil {
+		return nil, "", false, errors.New("model cannot be nil")
+	}
 	var isPointer bool
 
 	switch {
 	case modelType.Kind() == reflect.Ptr:
 		if isPrimitiveType(modelType.Elem().Kind()) {
-			tableName = strings.ToLower(modelType.Elem().Name())
 			modelType = modelType.Elem()
-			return modelType, tableName, isPointer, nil
+			return modelType, TableNameOf(modelType, naming), isPointer, nil
 		}
 		if modelType.Elem().Kind() == reflect.Struct {
-
-			tableName = strings.ToLower(modelType.Elem().Name())
 			modelType = modelType.Elem()
 		} else if modelType.Elem().Elem().Name() == "" {
-			tableName = strings.ToLower(modelType.Elem().Elem().Elem().Name())
 			modelType = modelType.Elem().Elem().Elem()
 			isPointer = true
 		} else {
-			tableName = strings.ToLower(modelType.Elem().Elem().Name())
 			modelType = modelType.Elem().Elem()
 		}
 	case modelType.Kind() == reflect.Slice:
 		if modelType.Elem().Kind() == reflect.Ptr {
-			tableName = strings.ToLower(modelType.Elem().Elem().Name())
 			modelType = modelType.Elem().Elem()
 			isPointer = true
 		} else {
-			tableName = strings.ToLower(modelType.Elem().Name())
 			modelType = modelType.Elem()
 		}
 	case modelType.Kind() == reflect.Struct:
-		tableName = strings.ToLower(modelType.Name())
 
 	default:
 		return nil, "", false, fmt.Errorf("unsupported model type: %s", modelType.Kind())
 	}
 
-	return modelType, tableName, isPointer, nil
+	return modelType, TableNameOf(modelType, naming), isPointer, nil
 }
 
 // MapRowsToModel maps database rows to the provided model
-func MapRowsToModel(rows *sql.Rows, model interface{}, modelType reflect.Type, isPointer bool) error {
+func MapRowsToModel(rows *sql.Rows, model interface{}, modelType reflect.Type, isPointer bool, naming NamingStrategy) error {
 	modelValue := reflect.ValueOf(model)
 	if modelValue.Kind() != reflect.Ptr && modelValue.Kind() != reflect.Slice {
 		return errors.New("model must be a pointer to a struct or slice")
@@ -108,15 +103,19 @@ func MapRowsToModel(rows *sql.Rows, model interface{}, modelType reflect.Type, i
 			return fmt.Errorf("targetValue must be a struct or a pointer to a struct, got %s", targetValue.Kind())
 		}
 
-		return ScanRow(rows, targetValue)
+		return ScanRow(rows, targetValue, naming)
 	}
 
 	if targetValue.Kind() == reflect.Slice {
+		fieldIndexes, err := columnFieldIndexes(rows, modelType, naming)
+		if err != nil {
+			return err
+		}
 
 		for rows.Next() {
 			elem := reflect.New(modelType)
 
-			if err := ScanRow(rows, elem.Elem()); err != nil {
+			if err := scanStruct(rows, elem.Elem(), fieldIndexes); err != nil {
 				return err
 			}
 			if isPointer {
@@ -133,7 +132,7 @@ func MapRowsToModel(rows *sql.Rows, model interface{}, modelType reflect.Type, i
 }
 
 // ScanRow scans a single row into a struct or a pointer to a struct
-func ScanRow(rows *sql.Rows, targetValue reflect.Value) error {
+func ScanRow(rows *sql.Rows, targetValue reflect.Value, naming NamingStrategy) error {
 	if targetValue.Kind() == reflect.Ptr {
 		if targetValue.IsNil() {
 			targetValue.Set(reflect.New(targetValue.Type().Elem()))
@@ -145,15 +144,55 @@ func ScanRow(rows *sql.Rows, targetValue reflect.Value) error {
 		return fmt.Errorf("targetValue must be a struct or a pointer to a struct, got %s", targetValue.Kind())
 	}
 
-	fieldPointers := make([]interface{}, targetValue.NumField())
-	for i := 0; i < targetValue.NumField(); i++ {
-		fieldPointers[i] = targetValue.Field(i).Addr().Interface()
+	fieldIndexes, err := columnFieldIndexes(rows, targetValue.Type(), naming)
+	if err != nil {
+		return err
+	}
+	return scanStruct(rows, targetValue, fieldIndexes)
+}
+
+// columnFieldIndexes resolves every result column to the struct field it is scanned into,
+// columns without a matching field get a nil field
+func columnFieldIndexes(rows *sql.Rows, structType reflect.Type, naming NamingStrategy) ([]*Field, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := FieldsByColumn(structType, naming)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([]*Field, len(columns))
+	seen := make(map[string]bool, len(columns))
+	for i, column := range columns {
+		column = strings.ToLower(column)
+		if seen[column] {
+			continue
+		}
+		seen[column] = true
+		indexes[i] = fields[column]
+	}
+	return indexes, nil
+}
+
+// scanStruct scans the current row into targetValue, it is reset first so unselected fields stay zeroed
+func scanStruct(rows *sql.Rows, targetValue reflect.Value, fields []*Field) error {
+	targetValue.Set(reflect.Zero(targetValue.Type()))
+
+	fieldPointers := make([]interface{}, len(fields))
+	for i, field := range fields {
+		if field == nil {
+			fieldPointers[i] = new(interface{})
+			continue
+		}
+		fieldPointers[i] = field.ScanTarget(targetValue)
 	}
 
 	return rows.Scan(fieldPointers...)
 }
 
-func MapRowsToSliceModel(rows *sql.Rows, model interface{}, modelType reflect.Type) error {
+func MapRowsToSliceModel(rows *sql.Rows, model interface{}, modelType reflect.Type, naming NamingStrategy) error {
 	modelValue := reflect.ValueOf(model)
 	if modelValue.Kind() != reflect.Ptr {
 		return errors.New("model must be a pointer to a slice")
@@ -163,9 +202,13 @@ func MapRowsToSliceModel(rows *sql.Rows, model interface{}, modelType reflect.Ty
 	if targetValue.Kind() != reflect.Slice {
 		return fmt.Errorf("targetValue must be a slice, got %s", targetValue.Kind())
 	}
+	fieldIndexes, err := columnFieldIndexes(rows, modelType, naming)
+	if err != nil {
+		return err
+	}
 	for rows.Next() {
 		elem := reflect.New(modelType)
-		if err := ScanRow(rows, elem.Elem()); err != nil {
+		if err := scanStruct(rows, elem.Elem(), fieldIndexes); err != nil {
 			return err
 		}
 		targetValue.Set(reflect.Append(targetValue, elem.Elem()))
@@ -173,6 +216,57 @@ func MapRowsToSliceModel(rows *sql.Rows, model interface{}, modelType reflect.Ty
 	return nil
 }
 
+// mapType is the row type of map models, e.g. the result of a GROUP BY
+var mapType = reflect.TypeOf(map[string]interface{}{})
+
+// MapModelType reports whether model is a pointer to a map[string]interface{} or to a slice of them
+func MapModelType(model interface{}) (reflect.Type, bool) {
+	modelType := reflect.TypeOf(model)
+	if modelType == nil || modelType.Kind() != reflect.Ptr {
+		return nil, false
+	}
+	modelType = modelType.Elem()
+	if modelType.Kind() == reflect.Slice {
+		modelType = modelType.Elem()
+	}
+	return mapType, modelType == mapType
+}
+
+// MapRowsToMaps scans rows into a pointer to a map, which receives the first row, or to a slice of maps
+// keyed by column name. Text the driver returns as bytes is stored as a string.
+func MapRowsToMaps(rows *sql.Rows, model interface{}) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	target := reflect.ValueOf(model).Elem()
+
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		if err := rows.Scan(pointers...); err != nil {
+			return err
+		}
+
+		row := make(map[string]interface{}, len(columns))
+		for i, column := range columns {
+			if text, ok := values[i].([]byte); ok {
+				values[i] = string(text)
+			}
+			row[column] = values[i]
+		}
+		if target.Kind() == reflect.Map {
+			target.Set(reflect.ValueOf(row))
+			return nil
+		}
+		target.Set(reflect.Append(target, reflect.ValueOf(row)))
+	}
+	return rows.Err()
+}
+
 // isPrimitiveType checks if a type is a primitive Go type.
 func isPrimitiveType(kind reflect.Kind) bool {
 	switch kind {
@@ -0,0 +1,337 @@
+package util
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// RelationKind tells how two models are associated
+type RelationKind int
+
+const (
+	// BelongsTo is a single associated model whose key is stored in the owner, e.g. Post.User
+	BelongsTo RelationKind = iota + 1
+	// HasOne is a single associated model storing the key of the owner
+	HasOne
+	// HasMany is a slice of associated models storing the key of the owner, e.g. User.Posts
+	HasMany
+	// ManyToMany is a slice of associated models linked to the owner by the rows of a join table, e.g. User.Roles
+	ManyToMany
+)
+
+// Relation is a struct typed field holding associated models
+type Relation struct {
+	Name  string
+	Index []int
+	Kind  RelationKind
+	// Type is the struct type of the associated model
+	Type reflect.Type
+	// ForeignKey is the field storing the key, it belongs to the owner for BelongsTo and to Type otherwise.
+	// For ManyToMany it is the key of Type the join table points to.
+	ForeignKey *Field
+	// References is the field the foreign key points to, it belongs to Type for BelongsTo and to the owner otherwise
+	References *Field
+	OnDelete   string
+	OnUpdate   string
+	// JoinTable links the models of a ManyToMany relation, its JoinForeignKey column holds the
+	// References value of the owner and its JoinReferences column the ForeignKey value of Type
+	JoinTable      string
+	JoinForeignKey string
+	JoinReferences string
+}
+
+// ForeignKey is a FOREIGN KEY constraint of a table
+type ForeignKey struct {
+	Column     string
+	Table      string
+	References string
+	OnDelete   string
+	OnUpdate   string
+}
+
+// Tag options configuring relationships
+const (
+	optionForeignKey            = "foreign_key"
+	optionReferences            = "references"
+	optionOnDelete              = "on_delete"
+	optionOnUpdate              = "on_update"
+	optionMany2Many             = "many2many"
+	optionJoinForeignKey        = "join_foreign_key"
+	optionJoinReferences        = "join_references"
+	optionAssociationReferences = "association_references"
+)
+
+// relationOptions are the tag keys besides the column grammar that ParseTag accepts
+var relationOptions = map[string]bool{
+	optionForeignKey:            true,
+	optionReferences:            true,
+	optionOnDelete:              true,
+	optionOnUpdate:              true,
+	optionMany2Many:             true,
+	optionJoinForeignKey:        true,
+	optionJoinReferences:        true,
+	optionAssociationReferences: true,
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// isRelationType reports whether a field of type t holds associated models rather than a column value:
+// structs, pointers to structs and slices of either which the driver cannot store by themselves
+func isRelationType(t reflect.Type, tag Tag) bool {
+	if tag.Serializer != "" {
+		return false
+	}
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || IsTimeType(t) {
+		return false
+	}
+	return !t.Implements(valuerType) && !reflect.PointerTo(t).Implements(valuerType) && !reflect.PointerTo(t).Implements(scannerType)
+}
+
+// RelatedType returns the struct type of the models a relation field holds
+func RelatedType(t reflect.Type) reflect.Type {
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// ParseRelations resolves the association fields of a struct type. The foreign_key and references
+// tag options name the key fields by Go field or column name, without them a BelongsTo key is the
+// field named after the association plus ID and a HasOne or HasMany key is named after the owner type
+// plus ID. on_delete and on_update set the referential actions of the constraint. A slice tagged
+// many2many:table is linked through that join table instead.
+func ParseRelations(structType reflect.Type, naming NamingStrategy) ([]*Relation, error) {
+	parsed := parseFields(structType, naming)
+	if parsed.err != nil {
+		return nil, parsed.err
+	}
+	parsed.relationsOnce.Do(func() {
+		for _, field := range parsed.relationFields {
+			relation, err := resolveRelation(structType, field, naming)
+			if err != nil {
+				parsed.relationsErr = fmt.Errorf("invalid relation %s.%s: %w", structType.Name(), field.Name, err)
+				return
+			}
+			parsed.relations = append(parsed.relations, relation)
+		}
+	})
+	return parsed.relations, parsed.relationsErr
+}
+
+func resolveRelation(owner reflect.Type, field *Field, naming NamingStrategy) (*Relation, error) {
+	related := RelatedType(field.Type)
+	onDelete, err := referentialAction(field.Tag.Options[optionOnDelete])
+	if err != nil {
+		return nil, err
+	}
+	onUpdate, err := referentialAction(field.Tag.Options[optionOnUpdate])
+	if err != nil {
+		return nil, err
+	}
+	relation := &Relation{
+		Name:     field.Name,
+		Index:    field.Index,
+		Type:     related,
+		OnDelete: onDelete,
+		OnUpdate: onUpdate,
+	}
+
+	foreignKey := field.Tag.Options[optionForeignKey]
+	references := referencedColumn(field.Tag.Options[optionReferences])
+
+	if joinTable, ok := field.Tag.Options[optionMany2Many]; ok {
+		return resolveManyToMany(relation, owner, field, joinTable, references, naming)
+	}
+
+	if field.Type.Kind() != reflect.Slice {
+		// a single model belongs to the owner when the owner stores the key
+		name := foreignKey
+		if name == "" {
+			name = field.Name + "ID"
+		}
+		if key, err := findField(owner, name, naming); err == nil {
+			relation.Kind = BelongsTo
+			relation.ForeignKey = key
+			relation.References, err = keyField(related, references, naming)
+			return relation, err
+		} else if foreignKey != "" {
+			if _, err := findField(related, foreignKey, naming); err != nil {
+				return nil, fmt.Errorf("foreign key %s is neither a field of %s nor of %s", foreignKey, owner.Name(), related.Name())
+			}
+		}
+		relation.Kind = HasOne
+	} else {
+		relation.Kind = HasMany
+	}
+
+	if foreignKey == "" {
+		foreignKey = owner.Name() + "ID"
+	}
+	relation.ForeignKey, err = findField(related, foreignKey, naming)
+	if err != nil {
+		return nil, err
+	}
+	relation.References, err = keyField(owner, references, naming)
+	return relation, err
+}
+
+// Constraint returns the foreign key backing the relation together with the table holding it,
+// ManyToMany relations are backed by the constraints of their join table instead
+func (r *Relation) Constraint(owner reflect.Type, naming NamingStrategy) (table string, foreignKey ForeignKey) {
+	foreignKey = ForeignKey{
+		Column:     r.ForeignKey.Column,
+		References: r.References.Column,
+		OnDelete:   r.OnDelete,
+		OnUpdate:   r.OnUpdate,
+	}
+	if r.Kind == BelongsTo {
+		foreignKey.Table = TableNameOf(r.Type, naming)
+		return TableNameOf(owner, naming), foreignKey
+	}
+	foreignKey.Table = TableNameOf(owner, naming)
+	return TableNameOf(r.Type, naming), foreignKey
+}
+
+// resolveManyToMany links owner and the associated type through joinTable. The join columns are named
+// after the type and key field they point to unless join_foreign_key and join_references name them.
+func resolveManyToMany(relation *Relation, owner reflect.Type, field *Field, joinTable, references string, naming NamingStrategy) (*Relation, error) {
+	if field.Type.Kind() != reflect.Slice {
+		return nil, fmt.Errorf("many2many needs a slice, got %s", field.Type)
+	}
+	if joinTable == "" {
+		return nil, fmt.Errorf("many2many needs the name of the join table")
+	}
+
+	if naming == nil {
+		naming = DefaultNaming
+	}
+	var err error
+	relation.Kind = ManyToMany
+	relation.JoinTable = joinTable
+	if relation.References, err = keyField(owner, references, naming); err != nil {
+		return nil, err
+	}
+	if relation.ForeignKey, err = keyField(relation.Type, field.Tag.Options[optionAssociationReferences], naming); err != nil {
+		return nil, err
+	}
+
+	relation.JoinForeignKey = field.Tag.Options[optionJoinForeignKey]
+	if relation.JoinForeignKey == "" {
+		relation.JoinForeignKey = naming.ColumnName(owner.Name() + relation.References.Name)
+	}
+	relation.JoinReferences = field.Tag.Options[optionJoinReferences]
+	if relation.JoinReferences == "" {
+		relation.JoinReferences = naming.ColumnName(relation.Type.Name() + relation.ForeignKey.Name)
+	}
+	if strings.EqualFold(relation.JoinForeignKey, relation.JoinReferences) {
+		return nil, fmt.Errorf("join table %s needs distinct join_foreign_key and join_references", joinTable)
+	}
+	return relation, nil
+}
+
+// TagForeignKeys returns the constraints declared on the columns of a struct type
+// with a references:table(column) option, e.g. `durazzo:"foreign_key references:user(id) on_delete:cascade"`
+func TagForeignKeys(structType reflect.Type, naming NamingStrategy) ([]ForeignKey, error) {
+	fields, err := ParseFields(structType, naming)
+	if err != nil {
+		return nil, err
+	}
+	var foreignKeys []ForeignKey
+	for _, field := range fields {
+		references, ok := field.Tag.Options[optionReferences]
+		if !ok {
+			continue
+		}
+		table, column, ok := strings.Cut(references, "(")
+		if !ok || !strings.HasSuffix(column, ")") || table == "" {
+			return nil, fmt.Errorf("references of %s.%s must look like table(column), got %q", structType.Name(), field.Name, references)
+		}
+		onDelete, err := referentialAction(field.Tag.Options[optionOnDelete])
+		if err != nil {
+			return nil, err
+		}
+		onUpdate, err := referentialAction(field.Tag.Options[optionOnUpdate])
+		if err != nil {
+			return nil, err
+		}
+		foreignKeys = append(foreignKeys, ForeignKey{
+			Column:     field.Column,
+			Table:      table,
+			References: strings.TrimSuffix(column, ")"),
+			OnDelete:   onDelete,
+			OnUpdate:   onUpdate,
+		})
+	}
+	return foreignKeys, nil
+}
+
+// findField looks a column field of structType up by its Go name or its column
+func findField(structType reflect.Type, name string, naming NamingStrategy) (*Field, error) {
+	fields, err := ParseFields(structType, naming)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range fields {
+		if field.Name == name || strings.EqualFold(field.Column, name) {
+			return field, nil
+		}
+	}
+	return nil, fmt.Errorf("%s has no field %s", structType.Name(), name)
+}
+
+// keyField returns the named field of structType or its primary key when name is empty
+func keyField(structType reflect.Type, name string, naming NamingStrategy) (*Field, error) {
+	if name != "" {
+		return findField(structType, name, naming)
+	}
+	fields, err := ParseFields(structType, naming)
+	if err != nil {
+		return nil, err
+	}
+	var primaryKey *Field
+	for _, field := range fields {
+		if field.Tag.PrimaryKey {
+			if primaryKey != nil {
+				return nil, fmt.Errorf("%s has a composite primary key, name the referenced field with references", structType.Name())
+			}
+			primaryKey = field
+		}
+	}
+	if primaryKey == nil {
+		return nil, fmt.Errorf("%s has no primary key", structType.Name())
+	}
+	return primaryKey, nil
+}
+
+// referencedColumn accepts both references:id and references:users(id) on a relation field
+func referencedColumn(references string) string {
+	if _, column, ok := strings.Cut(references, "("); ok {
+		return strings.TrimSuffix(column, ")")
+	}
+	return references
+}
+
+// referentialAction validates an on_delete or on_update option, e.g. cascade or set_null
+func referentialAction(action string) (string, error) {
+	if action == "" {
+		return "", nil
+	}
+	normalized := strings.ToUpper(strings.ReplaceAll(action, "_", " "))
+	switch normalized {
+	case "CASCADE", "SET NULL", "SET DEFAULT", "RESTRICT", "NO ACTION":
+		return normalized, nil
+	default:
+		return "", fmt.Errorf("unsupported referential action %q", action)
+	}
+}
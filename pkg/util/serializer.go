@@ -0,0 +1,86 @@
+package util
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// ColumnValue returns the value of the field of structValue as it is sent to the database
+func (f *Field) ColumnValue(structValue reflect.Value) interface{} {
+	return f.Serialize(structValue.FieldByIndex(f.Index).Interface())
+}
+
+// Serialize encodes a value of the field with its serializer, values of other fields are returned as is
+// except for the zero time of a soft delete field which is stored as NULL
+func (f *Field) Serialize(value interface{}) interface{} {
+	if f.zeroTimeIsNull() {
+		if t, ok := value.(time.Time); ok && t.IsZero() {
+			return nil
+		}
+	}
+	if f.Tag.Serializer == "" {
+		return value
+	}
+	return jsonValue{value: value}
+}
+
+// ScanTarget returns the destination the field of structValue is scanned through
+func (f *Field) ScanTarget(structValue reflect.Value) interface{} {
+	target := structValue.FieldByIndex(f.Index)
+	if f.Tag.Serializer != "" {
+		return &jsonScanner{target: target}
+	}
+	if f.zeroTimeIsNull() {
+		return &nullTimeScanner{target: target}
+	}
+	return target.Addr().Interface()
+}
+
+// jsonValue stores a value as JSON text, nil pointers, maps and slices are stored as NULL
+type jsonValue struct {
+	value interface{}
+}
+
+func (j jsonValue) Value() (driver.Value, error) {
+	value := reflect.ValueOf(j.value)
+	switch value.Kind() {
+	case reflect.Invalid:
+		return nil, nil
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if value.IsNil() {
+			return nil, nil
+		}
+	}
+	data, err := json.Marshal(j.value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JSON column: %w", err)
+	}
+	return string(data), nil
+}
+
+// jsonScanner decodes a JSON column into an addressable field
+type jsonScanner struct {
+	target reflect.Value
+}
+
+func (j *jsonScanner) Scan(src interface{}) error {
+	var data []byte
+	switch value := src.(type) {
+	case nil:
+		j.target.Set(reflect.Zero(j.target.Type()))
+		return nil
+	case []byte:
+		data = value
+	case string:
+		data = []byte(value)
+	default:
+		return fmt.Errorf("cannot decode JSON column from %T", src)
+	}
+	if err := json.Unmarshal(data, j.target.Addr().Interface()); err != nil {
+		return fmt.Errorf("failed to decode JSON column: %w", err)
+	}
+	return nil
+}
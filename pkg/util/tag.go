@@ -0,0 +1,367 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// TagName is the struct tag key read by durazzo
+const TagName = "durazzo"
+
+// Tag is the parsed content of a durazzo struct tag such as `durazzo:"column:email size:100 unique not_null"`.
+// Options are separated by spaces or semicolons, values may be wrapped in single quotes to contain either.
+type Tag struct {
+	Ignore        bool
+	Column        string
+	Type          string
+	Size          int
+	Precision     int
+	Scale         int
+	NotNull       bool
+	Default       string
+	HasDefault    bool
+	Unique        bool
+	Index         string
+	HasIndex      bool
+	PrimaryKey    bool
+	AutoIncrement *bool
+	SoftDelete    bool
+	// AutoCreateTime and AutoUpdateTime stamp the field on insert and on every update
+	AutoCreateTime bool
+	AutoUpdateTime bool
+	// Serializer names the encoding of values the driver cannot store as they are, only json is supported
+	Serializer string
+	// Embedded flattens the columns of a struct field into its parent, EmbeddedPrefix is prepended to their names
+	Embedded       bool
+	EmbeddedPrefix string
+	// Options holds the accepted keys that are not part of the column grammar, i.e. relationship settings
+	Options map[string]string
+}
+
+// ParseTag parses a durazzo struct tag
+func ParseTag(tag string) (Tag, error) {
+	parsed := Tag{Options: map[string]string{}}
+	if strings.TrimSpace(tag) == "-" {
+		parsed.Ignore = true
+		return parsed, nil
+	}
+
+	tokens, err := splitTag(tag)
+	if err != nil {
+		return parsed, err
+	}
+
+	for _, token := range tokens {
+		key, value, hasValue := strings.Cut(token, ":")
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if key != "default" {
+			// defaults are SQL expressions, their quotes belong to the literal
+			value = unquoteTagValue(value)
+		}
+
+		switch key {
+		case "-":
+			parsed.Ignore = true
+		case "column":
+			if value == "" {
+				return parsed, fmt.Errorf("tag option column needs a name")
+			}
+			parsed.Column = value
+		case "type":
+			if value == "" {
+				return parsed, fmt.Errorf("tag option type needs a value")
+			}
+			parsed.Type = value
+		case "size", "precision", "scale":
+			number, err := strconv.Atoi(value)
+			if key == "scale" {
+				// a scale of 0 declares a DECIMAL without fractional digits
+				if err != nil || number < 0 {
+					return parsed, fmt.Errorf("tag option scale needs a non-negative number, got %q", value)
+				}
+			} else if err != nil || number <= 0 {
+				return parsed, fmt.Errorf("tag option %s needs a positive number, got %q", key, value)
+			}
+			switch key {
+			case "size":
+				parsed.Size = number
+			case "precision":
+				parsed.Precision = number
+			case "scale":
+				parsed.Scale = number
+			}
+		case "not_null":
+			parsed.NotNull = true
+		case "default":
+			if !hasValue {
+				return parsed, fmt.Errorf("tag option default needs a value")
+			}
+			parsed.Default = value
+			parsed.HasDefault = true
+		case "unique":
+			parsed.Unique = true
+		case "index":
+			parsed.HasIndex = true
+			parsed.Index = value
+		case "primary_key":
+			parsed.PrimaryKey = true
+		case "soft_delete":
+			parsed.SoftDelete = true
+		case "serializer":
+			if value != "json" {
+				return parsed, fmt.Errorf("unsupported serializer %q", value)
+			}
+			parsed.Serializer = value
+		case "embedded":
+			parsed.Embedded = true
+		case "embedded_prefix":
+			if value == "" {
+				return parsed, fmt.Errorf("tag option embedded_prefix needs a value")
+			}
+			parsed.Embedded = true
+			parsed.EmbeddedPrefix = value
+		case "autocreatetime":
+			parsed.AutoCreateTime = true
+		case "autoupdatetime":
+			parsed.AutoUpdateTime = true
+		case "autoincrement":
+			enabled := true
+			if hasValue {
+				enabled, err = strconv.ParseBool(value)
+				if err != nil {
+					return parsed, fmt.Errorf("tag option autoincrement needs a boolean, got %q", value)
+				}
+			}
+			parsed.AutoIncrement = &enabled
+		default:
+			if !relationOptions[key] {
+				return parsed, fmt.Errorf("unknown tag option %q", key)
+			}
+			parsed.Options[key] = value
+		}
+	}
+	return parsed, nil
+}
+
+// splitTag splits a tag into its options, separators inside single quotes or parentheses are kept
+func splitTag(tag string) ([]string, error) {
+	var tokens []string
+	var current strings.Builder
+	inQuotes := false
+	depth := 0
+
+	for _, r := range tag {
+		switch {
+		case r == '\'':
+			inQuotes = !inQuotes
+		case r == '(' && !inQuotes:
+			depth++
+		case r == ')' && !inQuotes:
+			depth--
+		case (r == ' ' || r == ';') && !inQuotes && depth == 0:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+
+	if inQuotes {
+		return nil, fmt.Errorf("unterminated quote in tag %q", tag)
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in tag %q", tag)
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens, nil
+}
+
+func unquoteTagValue(value string) string {
+	if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' && !strings.Contains(value[1:len(value)-1], "'") {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// Field is an exported struct field stored in a column
+type Field struct {
+	Name   string
+	Index  []int
+	Type   reflect.Type
+	Column string
+	Tag    Tag
+	// Prefix is the embedded_prefix its Column starts with and Source the embedded struct type declaring it,
+	// both are only set for the fields of prefixed embedded structs
+	Prefix string
+	Source reflect.Type
+}
+
+// IsAutoIncrement reports whether the database generates the field value,
+// integer primary keys are auto-incremented unless the tag says otherwise
+func (f *Field) IsAutoIncrement() bool {
+	if f.Tag.AutoIncrement != nil {
+		return *f.Tag.AutoIncrement
+	}
+	if !f.Tag.PrimaryKey {
+		return false
+	}
+	switch f.Type.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	default:
+		return false
+	}
+}
+
+type parsedFields struct {
+	fields   []*Field
+	byColumn map[string]*Field
+	// relationFields are the struct typed fields holding associated models, resolved by ParseRelations
+	relationFields []*Field
+	relations      []*Relation
+	relationsErr   error
+	relationsOnce  sync.Once
+	err            error
+}
+
+type fieldsCacheKey struct {
+	structType reflect.Type
+	naming     NamingStrategy
+}
+
+var fieldsCache sync.Map
+
+// ParseFields returns the column fields of a struct type in declaration order, fields of
+// embedded structs and of struct fields tagged embedded are promoted and fields tagged with "-"
+// are skipped. Columns without a column tag are named by naming and prefixed by the embedded_prefix
+// of their struct, fields holding associated models are no columns.
+// The result is cached per type and naming strategy.
+func ParseFields(structType reflect.Type, naming NamingStrategy) ([]*Field, error) {
+	parsed := parseFields(structType, naming)
+	return parsed.fields, parsed.err
+}
+
+// FieldsByColumn maps the lower cased column names of a struct type to their field
+func FieldsByColumn(structType reflect.Type, naming NamingStrategy) (map[string]*Field, error) {
+	parsed := parseFields(structType, naming)
+	return parsed.byColumn, parsed.err
+}
+
+func parseFields(structType reflect.Type, naming NamingStrategy) *parsedFields {
+	if naming == nil {
+		naming = DefaultNaming
+	}
+	// strategies that cannot be map keys are parsed on every call
+	cacheable := reflect.TypeOf(naming).Comparable()
+	key := fieldsCacheKey{structType: structType, naming: naming}
+	if cacheable {
+		if cached, ok := fieldsCache.Load(key); ok {
+			return cached.(*parsedFields)
+		}
+	}
+
+	parsed := &parsedFields{}
+	if structType.Kind() != reflect.Struct {
+		parsed.err = fmt.Errorf("%s is not a struct", structType)
+		return parsed
+	}
+
+	parsed.err = collectFields(structType, nil, embedding{}, naming, &parsed.fields, &parsed.relationFields)
+	if parsed.err == nil {
+		parsed.fields = promoteFields(parsed.fields)
+		parsed.byColumn = make(map[string]*Field, len(parsed.fields))
+		for _, field := range parsed.fields {
+			parsed.byColumn[strings.ToLower(field.Column)] = field
+		}
+	}
+	if cacheable {
+		fieldsCache.Store(key, parsed)
+	}
+	return parsed
+}
+
+// embedding is the column prefix of the embedded struct fields are collected from
+type embedding struct {
+	prefix string
+	source reflect.Type
+}
+
+func collectFields(structType reflect.Type, parent []int, embedded embedding, naming NamingStrategy, fields, relations *[]*Field) error {
+	for i := 0; i < structType.NumField(); i++ {
+		structField := structType.Field(i)
+		index := append(parent[:len(parent):len(parent)], i)
+
+		tag, err := ParseTag(structField.Tag.Get(TagName))
+		if err != nil {
+			return fmt.Errorf("invalid tag on %s.%s: %w", structType.Name(), structField.Name, err)
+		}
+		if tag.Ignore {
+			continue
+		}
+
+		if structField.Type.Kind() == reflect.Struct && (tag.Embedded || structField.Anonymous && tag.Column == "") {
+			nested := embedded
+			if tag.EmbeddedPrefix != "" {
+				nested = embedding{prefix: embedded.prefix + tag.EmbeddedPrefix, source: structField.Type}
+			}
+			if err := collectFields(structField.Type, index, nested, naming, fields, relations); err != nil {
+				return err
+			}
+			continue
+		}
+		if !structField.IsExported() {
+			continue
+		}
+
+		if err := validateSoftDelete(structField.Type, tag); err != nil {
+			return fmt.Errorf("invalid tag on %s.%s: %w", structType.Name(), structField.Name, err)
+		}
+		if isRelationType(structField.Type, tag) {
+			*relations = append(*relations, &Field{Name: structField.Name, Index: index, Type: structField.Type, Tag: tag})
+			continue
+		}
+
+		column := tag.Column
+		if column == "" {
+			column = naming.ColumnName(structField.Name)
+		}
+		*fields = append(*fields, &Field{
+			Name:   structField.Name,
+			Index:  index,
+			Type:   structField.Type,
+			Column: embedded.prefix + column,
+			Tag:    tag,
+			Prefix: embedded.prefix,
+			Source: embedded.source,
+		})
+	}
+	return nil
+}
+
+// promoteFields keeps the shallowest field for every column, like Go does for embedded fields
+func promoteFields(fields []*Field) []*Field {
+	byColumn := make(map[string]*Field, len(fields))
+	for _, field := range fields {
+		existing, ok := byColumn[field.Column]
+		if !ok || len(field.Index) < len(existing.Index) {
+			byColumn[field.Column] = field
+		}
+	}
+
+	promoted := make([]*Field, 0, len(byColumn))
+	for _, field := range fields {
+		if byColumn[field.Column] == field {
+			promoted = append(promoted, field)
+		}
+	}
+	return promoted
+}
@@ -0,0 +1,46 @@
+package util
+
+import (
+	"database/sql"
+	"reflect"
+	"time"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	nullTimeType = reflect.TypeOf(sql.NullTime{})
+)
+
+// IsTimeType reports whether values of t are stored as timestamps, nullable times included
+func IsTimeType(t reflect.Type) bool {
+	return ValueType(t) == timeType
+}
+
+// IsAutoCreateTime reports whether the field is stamped when its row is inserted,
+// either through the autoCreateTime tag or by being a time field named CreatedAt
+func (f *Field) IsAutoCreateTime() bool {
+	return f.Tag.AutoCreateTime || (f.Name == "CreatedAt" && IsTimeType(f.Type))
+}
+
+// IsAutoUpdateTime reports whether the field is stamped when its row is inserted or updated,
+// either through the autoUpdateTime tag or by being a time field named UpdatedAt
+func (f *Field) IsAutoUpdateTime() bool {
+	return f.Tag.AutoUpdateTime || (f.Name == "UpdatedAt" && IsTimeType(f.Type))
+}
+
+// SetTime stores now in a time field, it reports false for field types that hold no time
+func SetTime(field reflect.Value, now time.Time) bool {
+	switch field.Type() {
+	case timeType:
+		field.Set(reflect.ValueOf(now))
+	case reflect.PointerTo(timeType):
+		field.Set(reflect.ValueOf(&now))
+	case nullTimeType:
+		field.Set(reflect.ValueOf(sql.NullTime{Time: now, Valid: true}))
+	case deletedAtType:
+		field.Set(reflect.ValueOf(DeletedAt{Time: now, Valid: true}))
+	default:
+		return false
+	}
+	return true
+}